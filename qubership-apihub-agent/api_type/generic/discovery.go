@@ -1,7 +1,9 @@
 package generic
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -18,9 +20,11 @@ import (
 )
 
 type DiscoveryRunner interface {
-	DiscoverDocuments(baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error)
-	GetDocumentsByRefs(baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error)
+	DiscoverDocuments(ctx context.Context, baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error)
+	GetDocumentsByRefs(ctx context.Context, baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error)
 	FilterRefsForApiType(refs []view.DocumentRef) []view.DocumentRef
+	// GetDiscoveryUrlKinds returns kinds of discovery urls probed by DiscoverDocuments
+	GetDiscoveryUrlKinds() []view.DiscoveryUrlKind
 	GetName() string
 }
 
@@ -29,19 +33,16 @@ const ConfigNameField = "name"
 const ConfigXApiKindField = "x-api-kind"
 const ConfigUrlsField = "urls"
 
-func GetRefsFromConfig(baseUrl string, configUrl string, timeout time.Duration) ([]view.DocumentRef, *view.EndpointCallInfo) {
+func GetRefsFromConfig(ctx context.Context, baseUrl string, configUrl string, timeout time.Duration) ([]view.DocumentRef, *view.EndpointCallInfo) {
 	specRefs := make([]view.DocumentRef, 0)
-	spec, _, err := GetGenericObjectFromUrl(baseUrl+configUrl, timeout) // TODO: refactor??
+	spec, _, err := GetGenericObjectFromUrl(ctx, baseUrl+configUrl, timeout) // TODO: refactor??
 	if err != nil {
 		log.Debugf("Failed to read spec from %v: %v", baseUrl+configUrl, err.Error())
-		var statusCode int
-		if customError, ok := err.(*exception.CustomError); ok {
-			statusCode, _ = strconv.Atoi(customError.Params["code"].(string))
-		}
 		return nil, &view.EndpointCallInfo{
 			Path:         configUrl,
-			StatusCode:   statusCode,
+			StatusCode:   GetStatusCode(err),
 			ErrorSummary: fmt.Sprintf("Failed to get config: %s", err.Error()),
+			Attempts:     client.GetCallAttempts(err),
 		}
 	}
 	// single url case
@@ -81,7 +82,19 @@ func GetRefsFromConfig(baseUrl string, configUrl string, timeout time.Duration)
 	return specRefs, nil
 }
 
-func GetAnyDocsByRefs(baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
+// GetStatusCode returns response code of the failed document request. Returns 0 if no response was received.
+func GetStatusCode(err error) int {
+	var customError *exception.CustomError
+	if errors.As(err, &customError) {
+		if code, ok := customError.Params["code"].(string); ok {
+			statusCode, _ := strconv.Atoi(code)
+			return statusCode
+		}
+	}
+	return 0
+}
+
+func GetAnyDocsByRefs(ctx context.Context, baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
 	if len(refs) == 0 {
 		return nil, nil, nil
 	}
@@ -111,17 +124,14 @@ func GetAnyDocsByRefs(baseUrl string, refs []view.DocumentRef, configPath string
 
 			fullUrl := baseUrl + url
 
-			data, err := client.GetRawDocumentFromUrl(fullUrl, string(ref.ApiType), ref.Timeout)
+			data, err := client.GetRawDocumentFromUrl(ctx, fullUrl, string(ref.ApiType), ref.Timeout)
 			if err != nil {
 				log.Debugf("Failed to get document from url %s: %s", fullUrl, err)
-				var statusCode int
-				if customError, ok := err.(*exception.CustomError); ok {
-					statusCode, _ = strconv.Atoi(customError.Params["code"].(string))
-				}
 				callResults[i] = view.EndpointCallInfo{
 					Path:         url,
-					StatusCode:   statusCode,
+					StatusCode:   GetStatusCode(err),
 					ErrorSummary: fmt.Sprintf("Failed to get document: %s", err.Error()),
+					Attempts:     client.GetCallAttempts(err),
 				}
 				if ref.Required {
 					errors[i] = fmt.Sprintf("Failed to get required document from url %s: %s", url, err)
@@ -138,6 +148,8 @@ func GetAnyDocsByRefs(baseUrl string, refs []view.DocumentRef, configPath string
 					XApiKind:   ref.XApiKind,
 					DocPath:    url,
 					ConfigPath: configPath,
+					Hash:       utils.GetContentHash(data),
+					Size:       len(data),
 				}
 			} else {
 				callResults[i] = view.EndpointCallInfo{
@@ -151,28 +163,34 @@ func GetAnyDocsByRefs(baseUrl string, refs []view.DocumentRef, configPath string
 	return utils.FilterResultDocuments(result), utils.FilterEndpointCallResults(callResults), utils.FilterResultErrors(errors)
 }
 
-func GetGenericObjectFromUrl(url string, timeout time.Duration) (view.JsonMap, string, error) {
-	specBytes, err := client.GetRawDocumentFromUrl(url, string(view.ATRest), timeout)
+func GetGenericObjectFromUrl(ctx context.Context, url string, timeout time.Duration) (view.JsonMap, string, error) {
+	spec, format, _, err := GetGenericObjectWithContentFromUrl(ctx, url, timeout)
+	return spec, format, err
+}
+
+// GetGenericObjectWithContentFromUrl returns raw content along with parsed object
+func GetGenericObjectWithContentFromUrl(ctx context.Context, url string, timeout time.Duration) (view.JsonMap, string, []byte, error) {
+	specBytes, err := client.GetRawDocumentFromUrl(ctx, url, string(view.ATRest), timeout)
 	if err != nil {
-		return nil, "", err
+		return nil, "", nil, err
 	}
 	if len(specBytes) == 0 {
-		return nil, "", fmt.Errorf("response body is empty")
+		return nil, "", nil, fmt.Errorf("response body is empty")
 	}
 	var spec view.JsonMap
 	jsonErr := json.Unmarshal(specBytes, &spec)
 	if jsonErr == nil {
-		return spec, view.FormatJson, nil
+		return spec, view.FormatJson, specBytes, nil
 	}
 	var body map[interface{}]interface{}
 	yamlErr := yaml.Unmarshal(specBytes, &body)
 	if yamlErr != nil {
 		// TODO: Both failed - what error should be in this case ?
-		return nil, "", fmt.Errorf("invalid JSON: %v", jsonErr)
+		return nil, "", nil, fmt.Errorf("invalid JSON: %v", jsonErr)
 	}
 	spec = view.ConvertYamlToJsonMap(body)
 	if spec == nil {
-		return nil, "", fmt.Errorf("YAML structure cannot be converted to JSON map")
+		return nil, "", nil, fmt.Errorf("YAML structure cannot be converted to JSON map")
 	}
-	return spec, view.FormatYaml, nil
+	return spec, view.FormatYaml, specBytes, nil
 }
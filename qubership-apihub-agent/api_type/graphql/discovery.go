@@ -1,17 +1,15 @@
 package graphql
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"regexp"
-	"strconv"
 	"sync"
 	"time"
 
 	"github.com/Netcracker/qubership-apihub-agent/api_type/generic"
 	"github.com/Netcracker/qubership-apihub-agent/client"
-	"github.com/Netcracker/qubership-apihub-agent/exception"
 	"github.com/Netcracker/qubership-apihub-agent/utils"
 	"github.com/Netcracker/qubership-apihub-agent/view"
 	log "github.com/sirupsen/logrus"
@@ -27,18 +25,18 @@ type graphqlDiscoveryRunner struct {
 const DefaultGraphqlSpecName = "Graphql specification"
 const DefaultGraphqlIntSpecName = "Graphql introspection"
 
-func (r graphqlDiscoveryRunner) DiscoverDocuments(baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
+func (r graphqlDiscoveryRunner) DiscoverDocuments(ctx context.Context, baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
 	var allCallResults []view.EndpointCallInfo
 
 	// Check for GraphQL config first
 	for _, url := range urls.GraphqlConfig {
-		configRefs, callResult := getRefsFromGraphqlConfig(baseUrl, url, timeout)
+		configRefs, callResult := getRefsFromGraphqlConfig(ctx, baseUrl, url, timeout)
 		if callResult != nil {
 			allCallResults = append(allCallResults, *callResult)
 		}
 		if len(configRefs) > 0 {
 			// Graphql config found
-			docs, callResults, err := r.GetDocumentsByRefs(baseUrl, configRefs, url)
+			docs, callResults, err := r.GetDocumentsByRefs(ctx, baseUrl, configRefs, url)
 			allCallResults = append(allCallResults, callResults...)
 			return docs, allCallResults, err
 		}
@@ -52,12 +50,12 @@ func (r graphqlDiscoveryRunner) DiscoverDocuments(baseUrl string, urls view.Docu
 	for _, url := range urls.GraphqlIntrospection {
 		refs = append(refs, view.DocumentRef{Url: url, ApiType: view.ATGraphql, Required: false, Timeout: timeout}) //TODO: Metadata: map[string]interface{}{"isIntrospection": true} ???
 	}
-	docs, callResults, err := r.GetDocumentsByRefs(baseUrl, refs, "")
+	docs, callResults, err := r.GetDocumentsByRefs(ctx, baseUrl, refs, "")
 	allCallResults = append(allCallResults, callResults...)
 	return docs, allCallResults, err
 }
 
-func (r graphqlDiscoveryRunner) GetDocumentsByRefs(baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
+func (r graphqlDiscoveryRunner) GetDocumentsByRefs(ctx context.Context, baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
 	filteredRefs := r.FilterRefsForApiType(refs) // take only appropriate api type
 	if len(filteredRefs) == 0 {
 		return nil, nil, nil
@@ -84,22 +82,18 @@ func (r graphqlDiscoveryRunner) GetDocumentsByRefs(baseUrl string, refs []view.D
 
 			var name, format, fileId string
 
-			err := checkGraphqlIntrospection(url, ref.Timeout)
+			content, err := checkGraphqlIntrospection(ctx, url, ref.Timeout)
 			if err != nil {
 				log.Debugf("Failed to read graphql introspection from %v: %v", url, err.Error())
 
-				err := checkGraphqlSpec(url, ref.Timeout)
+				content, err = checkGraphqlSpec(ctx, url, ref.Timeout)
 				if err != nil {
 					log.Debugf("Failed to read graphql spec from %v: %v", url, err.Error())
-					var customErr *exception.CustomError
-					var statusCode int
-					if errors.As(err, &customErr) {
-						statusCode, _ = strconv.Atoi(customErr.Params["code"].(string))
-					}
 					callResults[i] = view.EndpointCallInfo{
 						Path:         currentSpecUrl,
-						StatusCode:   statusCode,
+						StatusCode:   generic.GetStatusCode(err),
 						ErrorSummary: err.Error(),
+						Attempts:     client.GetCallAttempts(err),
 					}
 					if ref.Required {
 						errs[i] = fmt.Sprintf("Failed to read required graphql spec from %s: %s", url, err)
@@ -132,6 +126,8 @@ func (r graphqlDiscoveryRunner) GetDocumentsByRefs(baseUrl string, refs []view.D
 				XApiKind:   currentSpecRef.XApiKind,
 				DocPath:    currentSpecUrl,
 				ConfigPath: configPath,
+				Hash:       utils.GetContentHash(content),
+				Size:       len(content),
 			}
 		})
 	}
@@ -145,82 +141,85 @@ func (r graphqlDiscoveryRunner) FilterRefsForApiType(refs []view.DocumentRef) []
 	return utils.FilterRefsForApiType(refs, view.ATGraphql)
 }
 
+func (r graphqlDiscoveryRunner) GetDiscoveryUrlKinds() []view.DiscoveryUrlKind {
+	return []view.DiscoveryUrlKind{view.DUKGraphqlConfig, view.DUKGraphqlSchema, view.DUKGraphqlIntrospection}
+}
+
 func (r graphqlDiscoveryRunner) GetName() string {
 	return "graphql"
 }
 
-func getGraphqlIntrospectionFromUrl(url string, timeout time.Duration) (view.JsonMap, error) {
+func getGraphqlIntrospectionFromUrl(ctx context.Context, url string, timeout time.Duration) (view.JsonMap, []byte, error) {
 	log.Debugf("Sending graphql introspection discovery request to %s", url)
-	specBytes, err := client.GetRawGraphqlIntrospectionFromUrl(url, timeout)
+	specBytes, err := client.GetRawGraphqlIntrospectionFromUrl(ctx, url, timeout)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var spec view.JsonMap
 	err = json.Unmarshal(specBytes, &spec)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return spec, nil
+	return spec, specBytes, nil
 }
 
-func getGraphqlSpecFromUrl(url string, timeout time.Duration) ([]byte, error) {
+func getGraphqlSpecFromUrl(ctx context.Context, url string, timeout time.Duration) ([]byte, error) {
 	log.Debugf("Sending graphql spec discovery request to %s", url)
-	specBytes, err := client.GetRawDocumentFromUrl(url, string(view.ATGraphql), timeout)
+	specBytes, err := client.GetRawDocumentFromUrl(ctx, url, string(view.ATGraphql), timeout)
 	if err != nil {
 		return nil, err
 	}
 	return specBytes, nil
 }
 
-func checkGraphqlIntrospection(specUrl string, timeout time.Duration) error {
-	spec, err := getGraphqlIntrospectionFromUrl(specUrl, timeout)
+// checkGraphqlIntrospection returns content of the introspection if it's found at the url
+func checkGraphqlIntrospection(ctx context.Context, specUrl string, timeout time.Duration) ([]byte, error) {
+	spec, specBytes, err := getGraphqlIntrospectionFromUrl(ctx, specUrl, timeout)
 	if err != nil {
-		return fmt.Errorf("failed to get graphql introspection from '%v': %v", specUrl, err.Error())
+		return nil, fmt.Errorf("failed to get graphql introspection from '%v': %v", specUrl, err.Error())
 	}
 	if spec != nil {
 		if _, ok := spec["data"]; ok {
-			return nil
+			return specBytes, nil
 		}
 	}
-	return fmt.Errorf("incorrect graphql introspection found at url `%v`", specUrl)
+	return nil, fmt.Errorf("incorrect graphql introspection found at url `%v`", specUrl)
 }
 
-func checkGraphqlSpec(specUrl string, timeout time.Duration) error {
-	spec, err := getGraphqlSpecFromUrl(specUrl, timeout)
+// checkGraphqlSpec returns content of the graphql spec if it's found at the url
+func checkGraphqlSpec(ctx context.Context, specUrl string, timeout time.Duration) ([]byte, error) {
+	spec, err := getGraphqlSpecFromUrl(ctx, specUrl, timeout)
 	if err != nil {
-		return fmt.Errorf("failed to get graphql specification from '%v': %w", specUrl, err)
+		return nil, fmt.Errorf("failed to get graphql specification from '%v': %w", specUrl, err)
 	}
 	if spec != nil {
 		match, err := regexp.Match("type\\s+?\\S+?\\s+?{", spec)
 		if err != nil {
-			return fmt.Errorf("failed to check if content of url %s is graphql spec: %s", specUrl, err)
+			return nil, fmt.Errorf("failed to check if content of url %s is graphql spec: %s", specUrl, err)
 		}
 		if match {
-			return nil
+			return spec, nil
 		} else {
-			return fmt.Errorf("incorrect graphql spec found at url `%v`", specUrl)
+			return nil, fmt.Errorf("incorrect graphql spec found at url `%v`", specUrl)
 		}
 	}
-	return fmt.Errorf("incorrect graphql spec found at url `%v`", specUrl)
+	return nil, fmt.Errorf("incorrect graphql spec found at url `%v`", specUrl)
 }
 
 const GraphqlConfigUrlField = "url"
 const GraphqlConfigUrlsField = "urls"
 const GraphqlConfigNameField = "name"
 
-func getRefsFromGraphqlConfig(baseUrl string, graphqlConfigUrl string, timeout time.Duration) ([]view.DocumentRef, *view.EndpointCallInfo) {
+func getRefsFromGraphqlConfig(ctx context.Context, baseUrl string, graphqlConfigUrl string, timeout time.Duration) ([]view.DocumentRef, *view.EndpointCallInfo) {
 	graphqlSpecRefs := make([]view.DocumentRef, 0)
-	spec, _, err := generic.GetGenericObjectFromUrl(baseUrl+graphqlConfigUrl, timeout) // TODO: refactor
+	spec, _, err := generic.GetGenericObjectFromUrl(ctx, baseUrl+graphqlConfigUrl, timeout) // TODO: refactor
 	if err != nil {
 		log.Debugf("Failed to read json spec from %v: %v", baseUrl+graphqlConfigUrl, err.Error())
-		var statusCode int
-		if customError, ok := err.(*exception.CustomError); ok {
-			statusCode, _ = strconv.Atoi(customError.Params["code"].(string))
-		}
 		return nil, &view.EndpointCallInfo{
 			Path:         graphqlConfigUrl,
-			StatusCode:   statusCode,
+			StatusCode:   generic.GetStatusCode(err),
 			ErrorSummary: fmt.Sprintf("Failed to get GraphQL config: %s", err.Error()),
+			Attempts:     client.GetCallAttempts(err),
 		}
 	}
 	// single url case
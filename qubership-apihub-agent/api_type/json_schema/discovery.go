@@ -1,6 +1,7 @@
 package json_schema
 
 import (
+	"context"
 	"time"
 
 	"github.com/Netcracker/qubership-apihub-agent/api_type/generic"
@@ -15,19 +16,24 @@ func NewJsonSchemaDiscoveryRunner() generic.DiscoveryRunner {
 type jsonSchemaDiscoveryRunner struct {
 }
 
-func (j jsonSchemaDiscoveryRunner) DiscoverDocuments(baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
+func (j jsonSchemaDiscoveryRunner) DiscoverDocuments(ctx context.Context, baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
 	// No default paths for this type
 	return []view.Document{}, nil, nil
 }
 
-func (j jsonSchemaDiscoveryRunner) GetDocumentsByRefs(baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
-	return generic.GetAnyDocsByRefs(baseUrl, j.FilterRefsForApiType(refs), configPath)
+func (j jsonSchemaDiscoveryRunner) GetDocumentsByRefs(ctx context.Context, baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
+	return generic.GetAnyDocsByRefs(ctx, baseUrl, j.FilterRefsForApiType(refs), configPath)
 }
 
 func (j jsonSchemaDiscoveryRunner) FilterRefsForApiType(refs []view.DocumentRef) []view.DocumentRef {
 	return utils.FilterRefsForApiType(refs, view.ATJsonSchema)
 }
 
+func (j jsonSchemaDiscoveryRunner) GetDiscoveryUrlKinds() []view.DiscoveryUrlKind {
+	// No default paths for this type
+	return nil
+}
+
 func (j jsonSchemaDiscoveryRunner) GetName() string {
 	return "json-schema"
 }
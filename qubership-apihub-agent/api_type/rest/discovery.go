@@ -1,14 +1,14 @@
 package rest
 
 import (
+	"context"
 	"fmt"
 	"regexp"
-	"strconv"
 	"sync"
 	"time"
 
 	"github.com/Netcracker/qubership-apihub-agent/api_type/generic"
-	"github.com/Netcracker/qubership-apihub-agent/exception"
+	"github.com/Netcracker/qubership-apihub-agent/client"
 	"github.com/Netcracker/qubership-apihub-agent/utils"
 	"github.com/Netcracker/qubership-apihub-agent/view"
 	log "github.com/sirupsen/logrus"
@@ -21,31 +21,31 @@ func NewRestDiscoveryRunner() generic.DiscoveryRunner {
 type restDiscoveryRunner struct {
 }
 
-func (r restDiscoveryRunner) DiscoverDocuments(baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
+func (r restDiscoveryRunner) DiscoverDocuments(ctx context.Context, baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
 	var allCallResults []view.EndpointCallInfo
 
 	// find swagger-config, etc..
 	var refs []view.DocumentRef
 	for _, url := range urls.SwaggerConfig {
-		refs, callResult := getRefsFromSwaggerConfig(baseUrl, url, timeout)
+		refs, callResult := getRefsFromSwaggerConfig(ctx, baseUrl, url, timeout)
 		if callResult != nil {
 			allCallResults = append(allCallResults, *callResult)
 		}
 		if len(refs) > 0 {
 			// Swagger config found
-			docs, callResults, err := r.GetDocumentsByRefs(baseUrl, refs, url)
+			docs, callResults, err := r.GetDocumentsByRefs(ctx, baseUrl, refs, url)
 			allCallResults = append(allCallResults, callResults...)
 			return docs, allCallResults, err
 		}
 	}
 	// Swagger config not found, generate refs list from openapi urls
 	refs = utils.MakeDocumentRefsFromUrls(urls.Openapi, view.ATRest, false, timeout)
-	docs, callResults, err := r.GetDocumentsByRefs(baseUrl, refs, "")
+	docs, callResults, err := r.GetDocumentsByRefs(ctx, baseUrl, refs, "")
 	allCallResults = append(allCallResults, callResults...)
 	return docs, allCallResults, err
 }
 
-func (r restDiscoveryRunner) GetDocumentsByRefs(baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
+func (r restDiscoveryRunner) GetDocumentsByRefs(ctx context.Context, baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
 	filteredRefs := r.FilterRefsForApiType(refs) // take only appropriate api type
 	if len(filteredRefs) == 0 {
 		return nil, nil, nil
@@ -70,7 +70,7 @@ func (r restDiscoveryRunner) GetDocumentsByRefs(baseUrl string, refs []view.Docu
 
 			url := baseUrl + currentSpecUrl
 
-			specVersion, specTitle, specFormat, callResult := getSpecVersionAndTitleFromDoc(url, currentSpecUrl, ref.Timeout)
+			specVersion, specTitle, specFormat, specBytes, callResult := getSpecVersionAndTitleFromDoc(ctx, url, currentSpecUrl, ref.Timeout)
 			if callResult != nil {
 				log.Debugf("Failed to read openapi spec from %s: %s", url, callResult.ErrorSummary)
 				callResults[i] = *callResult
@@ -98,6 +98,8 @@ func (r restDiscoveryRunner) GetDocumentsByRefs(baseUrl string, refs []view.Docu
 				XApiKind:   currentSpecRef.XApiKind,
 				DocPath:    currentSpecUrl,
 				ConfigPath: configPath,
+				Hash:       utils.GetContentHash(specBytes),
+				Size:       len(specBytes),
 			}
 		})
 	}
@@ -114,8 +116,8 @@ var openapi2Regexp = regexp.MustCompile(`2.*`)
 
 const DefaultOpenapiSpecName = "default"
 
-func getRefsFromSwaggerConfig(baseUrl string, swaggerConfigUrl string, timeout time.Duration) ([]view.DocumentRef, *view.EndpointCallInfo) {
-	swaggerSpecRefs, callResult := generic.GetRefsFromConfig(baseUrl, swaggerConfigUrl, timeout)
+func getRefsFromSwaggerConfig(ctx context.Context, baseUrl string, swaggerConfigUrl string, timeout time.Duration) ([]view.DocumentRef, *view.EndpointCallInfo) {
+	swaggerSpecRefs, callResult := generic.GetRefsFromConfig(ctx, baseUrl, swaggerConfigUrl, timeout)
 	if callResult != nil {
 		return nil, callResult
 	}
@@ -126,17 +128,15 @@ func getRefsFromSwaggerConfig(baseUrl string, swaggerConfigUrl string, timeout t
 	return swaggerSpecRefs, nil
 }
 
-func getSpecVersionAndTitleFromDoc(specUrl string, relativePath string, timeout time.Duration) (string, string, string, *view.EndpointCallInfo) {
-	spec, specFormat, err := generic.GetGenericObjectFromUrl(specUrl, timeout)
+// getSpecVersionAndTitleFromDoc returns spec version, title, format and raw content of the document
+func getSpecVersionAndTitleFromDoc(ctx context.Context, specUrl string, relativePath string, timeout time.Duration) (string, string, string, []byte, *view.EndpointCallInfo) {
+	spec, specFormat, specBytes, err := generic.GetGenericObjectWithContentFromUrl(ctx, specUrl, timeout)
 	if err != nil {
-		var statusCode int
-		if customError, ok := err.(*exception.CustomError); ok {
-			statusCode, _ = strconv.Atoi(customError.Params["code"].(string))
-		}
-		return "", "", "", &view.EndpointCallInfo{
+		return "", "", "", nil, &view.EndpointCallInfo{
 			Path:         relativePath,
-			StatusCode:   statusCode,
+			StatusCode:   generic.GetStatusCode(err),
 			ErrorSummary: fmt.Sprintf("failed to get OpenAPI specification: %v", err.Error()),
+			Attempts:     client.GetCallAttempts(err),
 		}
 	}
 	infoObject := spec.GetObject("info")
@@ -145,28 +145,28 @@ func getSpecVersionAndTitleFromDoc(specUrl string, relativePath string, timeout
 	openapiVersion := spec.GetValueAsString("openapi")
 	swaggerVersion := spec.GetValueAsString("swagger")
 	if openapi3Regexp.MatchString(openapiVersion) {
-		return view.OpenAPI30Type, title + " " + version, specFormat, nil
+		return view.OpenAPI30Type, title + " " + version, specFormat, specBytes, nil
 	}
 	if openapi31Regexp.MatchString(openapiVersion) {
-		return view.OpenAPI31Type, title + " " + version, specFormat, nil
+		return view.OpenAPI31Type, title + " " + version, specFormat, specBytes, nil
 	}
 	if openapi2Regexp.MatchString(swaggerVersion) || openapi2Regexp.MatchString(openapiVersion) {
-		return view.OpenAPI20Type, title + " " + version, specFormat, nil
+		return view.OpenAPI20Type, title + " " + version, specFormat, specBytes, nil
 	}
 
 	if openapiVersion != "" {
-		return "", "", "", &view.EndpointCallInfo{
+		return "", "", "", nil, &view.EndpointCallInfo{
 			Path:         relativePath,
 			ErrorSummary: fmt.Sprintf("unsupported OpenAPI version: %s (expected 2.x, 3.0.x, or 3.1.x)", openapiVersion),
 		}
 	}
 	if swaggerVersion != "" {
-		return "", "", "", &view.EndpointCallInfo{
+		return "", "", "", nil, &view.EndpointCallInfo{
 			Path:         relativePath,
 			ErrorSummary: fmt.Sprintf("unsupported Swagger version: %s (expected 2.x)", swaggerVersion),
 		}
 	}
-	return "", "", "", &view.EndpointCallInfo{
+	return "", "", "", nil, &view.EndpointCallInfo{
 		Path:         relativePath,
 		ErrorSummary: "response is valid JSON but missing 'openapi' or 'swagger' version field",
 	}
@@ -176,6 +176,10 @@ func (r restDiscoveryRunner) FilterRefsForApiType(refs []view.DocumentRef) []vie
 	return utils.FilterRefsForApiType(refs, view.ATRest)
 }
 
+func (r restDiscoveryRunner) GetDiscoveryUrlKinds() []view.DiscoveryUrlKind {
+	return []view.DiscoveryUrlKind{view.DUKSwaggerConfig, view.DUKOpenapi}
+}
+
 func (r restDiscoveryRunner) GetName() string {
 	return "rest"
 }
@@ -1,6 +1,7 @@
 package smartplug
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -16,17 +17,17 @@ func NewSmartplugDiscoveryRunner() generic.DiscoveryRunner {
 type smartplugDiscoveryRunner struct {
 }
 
-func (m smartplugDiscoveryRunner) DiscoverDocuments(baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
+func (m smartplugDiscoveryRunner) DiscoverDocuments(ctx context.Context, baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
 	var allCallResults []view.EndpointCallInfo
 
 	for _, url := range urls.SmartplugConfig {
-		refs, configPath, callResult := m.getRefsFromSmartplugConfig(baseUrl, url, timeout)
+		refs, configPath, callResult := m.getRefsFromSmartplugConfig(ctx, baseUrl, url, timeout)
 		if callResult != nil {
 			allCallResults = append(allCallResults, *callResult)
 		}
 		if len(refs) > 0 {
 			// config found
-			docs, callResults, err := m.GetDocumentsByRefs(baseUrl, refs, configPath)
+			docs, callResults, err := m.GetDocumentsByRefs(ctx, baseUrl, refs, configPath)
 			allCallResults = append(allCallResults, callResults...)
 			return docs, allCallResults, err
 		}
@@ -34,8 +35,8 @@ func (m smartplugDiscoveryRunner) DiscoverDocuments(baseUrl string, urls view.Do
 	return nil, allCallResults, nil
 }
 
-func (m smartplugDiscoveryRunner) getRefsFromSmartplugConfig(baseUrl string, smartplugConfigUrl string, timeout time.Duration) ([]view.DocumentRef, string, *view.EndpointCallInfo) {
-	smartplugSpecRefs, callResult := generic.GetRefsFromConfig(baseUrl, smartplugConfigUrl, timeout)
+func (m smartplugDiscoveryRunner) getRefsFromSmartplugConfig(ctx context.Context, baseUrl string, smartplugConfigUrl string, timeout time.Duration) ([]view.DocumentRef, string, *view.EndpointCallInfo) {
+	smartplugSpecRefs, callResult := generic.GetRefsFromConfig(ctx, baseUrl, smartplugConfigUrl, timeout)
 	if callResult != nil {
 		return nil, "", callResult
 	}
@@ -45,8 +46,8 @@ func (m smartplugDiscoveryRunner) getRefsFromSmartplugConfig(baseUrl string, sma
 	return smartplugSpecRefs, smartplugConfigUrl, nil
 }
 
-func (m smartplugDiscoveryRunner) GetDocumentsByRefs(baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
-	docs, callResults, err := generic.GetAnyDocsByRefs(baseUrl, m.FilterRefsForApiType(refs), configPath)
+func (m smartplugDiscoveryRunner) GetDocumentsByRefs(ctx context.Context, baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
+	docs, callResults, err := generic.GetAnyDocsByRefs(ctx, baseUrl, m.FilterRefsForApiType(refs), configPath)
 	if err != nil {
 		return docs, callResults, err
 	}
@@ -62,6 +63,10 @@ func (m smartplugDiscoveryRunner) FilterRefsForApiType(refs []view.DocumentRef)
 	return utils.FilterRefsForApiType(refs, view.ATSmartplug)
 }
 
+func (m smartplugDiscoveryRunner) GetDiscoveryUrlKinds() []view.DiscoveryUrlKind {
+	return []view.DiscoveryUrlKind{view.DUKSmartplugConfig}
+}
+
 func (m smartplugDiscoveryRunner) GetName() string {
 	return "smartplug"
 }
@@ -1,6 +1,7 @@
 package unknown
 
 import (
+	"context"
 	"time"
 
 	"github.com/Netcracker/qubership-apihub-agent/api_type/generic"
@@ -15,19 +16,24 @@ func NewUnknownDiscoveryRunner() generic.DiscoveryRunner {
 type unknownDiscoveryRunner struct {
 }
 
-func (m unknownDiscoveryRunner) DiscoverDocuments(baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
+func (m unknownDiscoveryRunner) DiscoverDocuments(ctx context.Context, baseUrl string, urls view.DocumentDiscoveryUrls, timeout time.Duration) ([]view.Document, []view.EndpointCallInfo, error) {
 	// No default paths for this type
 	return []view.Document{}, nil, nil
 }
 
-func (m unknownDiscoveryRunner) GetDocumentsByRefs(baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
-	return generic.GetAnyDocsByRefs(baseUrl, m.FilterRefsForApiType(refs), configPath)
+func (m unknownDiscoveryRunner) GetDocumentsByRefs(ctx context.Context, baseUrl string, refs []view.DocumentRef, configPath string) ([]view.Document, []view.EndpointCallInfo, error) {
+	return generic.GetAnyDocsByRefs(ctx, baseUrl, m.FilterRefsForApiType(refs), configPath)
 }
 
 func (m unknownDiscoveryRunner) FilterRefsForApiType(refs []view.DocumentRef) []view.DocumentRef {
 	return utils.FilterRefsForApiType(refs, view.ATUnknown)
 }
 
+func (m unknownDiscoveryRunner) GetDiscoveryUrlKinds() []view.DiscoveryUrlKind {
+	// No default paths for this type
+	return nil
+}
+
 func (m unknownDiscoveryRunner) GetName() string {
 	return "unknown"
 }
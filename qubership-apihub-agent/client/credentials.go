@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// DocumentCredentials are attached to the requests for documents of services which protect them with auth
+type DocumentCredentials struct {
+	BearerToken string
+	Username    string
+	Password    string
+	Headers     map[string]string
+}
+
+type documentCredentialsKey struct{}
+
+// WithDocumentCredentials returns context, requests for documents made with which are sent with the credentials
+func WithDocumentCredentials(ctx context.Context, credentials *DocumentCredentials) context.Context {
+	if credentials == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, documentCredentialsKey{}, credentials)
+}
+
+// AddDocumentCredentials sets auth headers to the request if its context holds document credentials
+func AddDocumentCredentials(req *http.Request) {
+	credentials, ok := req.Context().Value(documentCredentialsKey{}).(*DocumentCredentials)
+	if !ok {
+		return
+	}
+	for name, value := range credentials.Headers {
+		req.Header.Set(name, value)
+	}
+	if credentials.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+credentials.BearerToken)
+	} else if credentials.Username != "" {
+		req.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRawDocumentSendsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	_, err := GetRawDocumentFromUrl(context.Background(), server.URL, "rest", time.Second)
+	assert.Error(t, err)
+
+	ctx := WithDocumentCredentials(context.Background(), &DocumentCredentials{BearerToken: "secret-token", Headers: map[string]string{"X-Api-Key": "key"}})
+	data, err := GetRawDocumentFromUrl(ctx, server.URL, "rest", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+}
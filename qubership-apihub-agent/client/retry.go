@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	log "github.com/sirupsen/logrus"
+)
+
+type retryPolicy struct {
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+var documentRetryPolicy = retryPolicy{}
+
+// maxRetryAfterWithoutDeadline limits the delay from Retry-After header for the requests without deadline
+const maxRetryAfterWithoutDeadline = time.Minute
+
+// SetDocumentRetryPolicy enables retries of document requests failed with network error or 5xx/429 response. Backoff is doubled after each attempt up to maxBackoff.
+// Delay from Retry-After header is not limited by maxBackoff, the request is not retried if the delay exceeds its deadline.
+func SetDocumentRetryPolicy(maxRetries int, initialBackoff time.Duration, maxBackoff time.Duration) {
+	documentRetryPolicy = retryPolicy{maxRetries: maxRetries, initialBackoff: initialBackoff, maxBackoff: maxBackoff}
+}
+
+// RetriesExhaustedError is returned when the request failed after retries. Err is the error of the last attempt.
+type RetriesExhaustedError struct {
+	Err      error
+	Attempts []view.EndpointCallAttempt
+}
+
+func (e *RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("%s (after %d attempts)", e.Err.Error(), len(e.Attempts))
+}
+
+func (e *RetriesExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// GetCallAttempts returns history of the failed request attempts. Returns nil if the request was not retried.
+func GetCallAttempts(err error) []view.EndpointCallAttempt {
+	var retriesErr *RetriesExhaustedError
+	if errors.As(err, &retriesErr) {
+		return retriesErr.Attempts
+	}
+	return nil
+}
+
+// RetriedCalls collects the calls which succeeded after retries, so their history is not lost
+type RetriedCalls struct {
+	mutex sync.Mutex
+	calls []view.EndpointCallInfo
+}
+
+type retriedCallsKey struct{}
+
+// WithRetriedCalls returns context, requests for documents made with which are recorded to the returned RetriedCalls if they succeed after retries
+func WithRetriedCalls(ctx context.Context) (context.Context, *RetriedCalls) {
+	retriedCalls := &RetriedCalls{}
+	return context.WithValue(ctx, retriedCallsKey{}, retriedCalls), retriedCalls
+}
+
+// Get returns recorded calls, Path of the call is the full url of the request
+func (r *RetriedCalls) Get() []view.EndpointCallInfo {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]view.EndpointCallInfo(nil), r.calls...)
+}
+
+func recordRetriedCall(ctx context.Context, url string, statusCode int, attempts []view.EndpointCallAttempt) {
+	retriedCalls, ok := ctx.Value(retriedCallsKey{}).(*RetriedCalls)
+	if !ok || len(attempts) < 2 {
+		return
+	}
+	retriedCalls.mutex.Lock()
+	defer retriedCalls.mutex.Unlock()
+	retriedCalls.calls = append(retriedCalls.calls, view.EndpointCallInfo{
+		Path:       url,
+		StatusCode: statusCode,
+		Attempts:   attempts,
+	})
+}
+
+type withoutRetriesKey struct{}
+
+// WithoutRetries returns context, requests for documents made with which are not retried. Used for speculative requests to the ports which may not serve http at all.
+func WithoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutRetriesKey{}, true)
+}
+
+// doWithRetries sends the request created by makeRequest until it succeeds, fails with non retryable error or retries are exhausted.
+// Returns response or error of the last attempt along with all attempts. Response must be closed by the caller.
+func doWithRetries(ctx context.Context, client *http.Client, makeRequest func() (*http.Request, error)) (*http.Response, []view.EndpointCallAttempt, error) {
+	policy := documentRetryPolicy
+	if ctx.Value(withoutRetriesKey{}) != nil {
+		policy.maxRetries = 0
+	}
+	var attempts []view.EndpointCallAttempt
+	backoff := policy.initialBackoff
+	for attempt := 0; ; attempt++ {
+		req, err := makeRequest()
+		if err != nil {
+			return nil, attempts, err
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+
+		callAttempt := view.EndpointCallAttempt{DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			callAttempt.ErrorSummary = err.Error()
+		} else {
+			callAttempt.StatusCode = resp.StatusCode
+		}
+		attempts = append(attempts, callAttempt)
+
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			recordRetriedCall(ctx, req.URL.String(), resp.StatusCode, attempts)
+			return resp, attempts, nil
+		}
+		if attempt >= policy.maxRetries || ctx.Err() != nil {
+			return resp, attempts, err
+		}
+
+		delay := backoff
+		if delay > policy.maxBackoff {
+			delay = policy.maxBackoff
+		}
+		// jitter prevents simultaneous retries of all requests to restarted service
+		delay += time.Duration(rand.Int63n(int64(delay)/10 + 1))
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok && retryAfter > delay {
+				if retryAfter > maxRetryDelay(ctx) {
+					log.Debugf("Request to %s is not retried: Retry-After %v exceeds the request deadline", req.URL.String(), retryAfter)
+					return resp, attempts, err
+				}
+				delay = retryAfter
+			}
+			resp.Body.Close()
+		}
+		log.Debugf("Retrying request to %s in %v, attempt %d failed: %+v", req.URL.String(), delay, attempt+1, callAttempt)
+		select {
+		case <-ctx.Done():
+			return nil, attempts, ctx.Err()
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+}
+
+// maxRetryDelay returns time left till the request deadline
+func maxRetryDelay(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return maxRetryAfterWithoutDeadline
+}
+
+// withAttempts adds history of attempts to the error if the request was retried
+func withAttempts(err error, attempts []view.EndpointCallAttempt) error {
+	if len(attempts) < 2 {
+		return err
+	}
+	return &RetriesExhaustedError{Err: err, Attempts: attempts}
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusTooManyRequests
+}
+
+// parseRetryAfter supports both delay-seconds and HTTP-date formats
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRawDocumentRetriesTransientFailures(t *testing.T) {
+	SetDocumentRetryPolicy(2, time.Millisecond, 10*time.Millisecond)
+	defer SetDocumentRetryPolicy(0, 0, 0)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	data, err := GetRawDocumentFromUrl(context.Background(), server.URL, "rest", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestGetRawDocumentRecordsAttempts(t *testing.T) {
+	SetDocumentRetryPolicy(2, time.Millisecond, 10*time.Millisecond)
+	defer SetDocumentRetryPolicy(0, 0, 0)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := GetRawDocumentFromUrl(context.Background(), server.URL, "rest", time.Second)
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	attempts := GetCallAttempts(err)
+	assert.Len(t, attempts, 3)
+	for _, attempt := range attempts {
+		assert.Equal(t, http.StatusBadGateway, attempt.StatusCode)
+	}
+}
+
+func TestGetRawDocumentDoesNotRetryClientErrors(t *testing.T) {
+	SetDocumentRetryPolicy(2, time.Millisecond, 10*time.Millisecond)
+	defer SetDocumentRetryPolicy(0, 0, 0)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := GetRawDocumentFromUrl(context.Background(), server.URL, "rest", time.Second)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Nil(t, GetCallAttempts(err))
+}
+
+func TestGetRawDocumentRecordsAttemptsOfRetriedCall(t *testing.T) {
+	SetDocumentRetryPolicy(2, time.Millisecond, 10*time.Millisecond)
+	defer SetDocumentRetryPolicy(0, 0, 0)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	ctx, retriedCalls := WithRetriedCalls(context.Background())
+	_, err := GetRawDocumentFromUrl(ctx, server.URL+"/doc", "rest", time.Second)
+	assert.NoError(t, err)
+	recorded := retriedCalls.Get()
+	assert.Len(t, recorded, 1)
+	assert.Equal(t, server.URL+"/doc", recorded[0].Path)
+	assert.Equal(t, http.StatusOK, recorded[0].StatusCode)
+	assert.Len(t, recorded[0].Attempts, 2)
+	assert.Equal(t, http.StatusServiceUnavailable, recorded[0].Attempts[0].StatusCode)
+	assert.Equal(t, http.StatusOK, recorded[0].Attempts[1].StatusCode)
+}
+
+func TestGetRawDocumentDoesNotRetryBeforeRetryAfter(t *testing.T) {
+	SetDocumentRetryPolicy(2, time.Millisecond, 10*time.Millisecond)
+	defer SetDocumentRetryPolicy(0, 0, 0)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := GetRawDocumentFromUrl(ctx, server.URL, "rest", time.Second)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGetRawDocumentIsNotRetriedWithoutRetries(t *testing.T) {
+	SetDocumentRetryPolicy(2, time.Millisecond, 10*time.Millisecond)
+	defer SetDocumentRetryPolicy(0, 0, 0)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := GetRawDocumentFromUrl(WithoutRetries(context.Background()), server.URL, "rest", time.Second)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -11,31 +12,29 @@ import (
 	"github.com/Netcracker/qubership-apihub-agent/utils"
 )
 
-func GetRawGraphqlIntrospectionFromUrl(url string, timeout time.Duration) ([]byte, error) {
+func GetRawGraphqlIntrospectionFromUrl(ctx context.Context, url string, timeout time.Duration) ([]byte, error) {
 	client := utils.MakeDiscoveryHttpClient(timeout)
 
 	start := time.Now()
-	req, err := http.NewRequest(http.MethodPost, url, nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := client.Do(req)
+	resp, attempts, err := doWithRetries(ctx, &client, func() (*http.Request, error) {
+		return newDocumentRequest(ctx, http.MethodPost, url)
+	})
 	if err != nil {
 
 		utils.PerfLog(time.Since(start).Milliseconds(), timeout.Milliseconds()+500, fmt.Sprintf("Get raw graphql introspection from URL %s with err %s", url, err))
-		return nil, err
+		return nil, withAttempts(err, attempts)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		utils.PerfLog(time.Since(start).Milliseconds(), timeout.Milliseconds()+500, fmt.Sprintf("Get raw graphql introspection from URL %s with resp code %d", url, resp.StatusCode))
-		return nil, &exception.CustomError{
+		return nil, withAttempts(&exception.CustomError{
 			Status:  http.StatusFailedDependency,
 			Code:    exception.FailedToDownloadSpec,
 			Message: exception.FailedToDownloadSpecMsg,
 			Params:  map[string]interface{}{"code": strconv.Itoa(resp.StatusCode)},
 			Debug:   fmt.Sprintf("unable to get graphql introspection from url %s: incorrect response code: %d", url, resp.StatusCode),
-		}
+		}, attempts)
 	}
-	defer resp.Body.Close()
 	bytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		utils.PerfLog(time.Since(start).Milliseconds(), timeout.Milliseconds()+500, fmt.Sprintf("Get raw graphql introspection from URL %s with body read err %s", url, err))
@@ -45,25 +44,27 @@ func GetRawGraphqlIntrospectionFromUrl(url string, timeout time.Duration) ([]byt
 	return bytes, nil
 }
 
-func GetRawDocumentFromUrl(url, documentType string, timeout time.Duration) ([]byte, error) {
+func GetRawDocumentFromUrl(ctx context.Context, url, documentType string, timeout time.Duration) ([]byte, error) {
 	client := utils.MakeDiscoveryHttpClient(timeout)
 	start := time.Now()
-	resp, err := client.Get(url)
+	resp, attempts, err := doWithRetries(ctx, &client, func() (*http.Request, error) {
+		return newDocumentRequest(ctx, http.MethodGet, url)
+	})
 	if err != nil {
 		utils.PerfLog(time.Since(start).Milliseconds(), timeout.Milliseconds()+500, fmt.Sprintf("Get raw document from URL %s with err %s", url, err))
-		return nil, err
+		return nil, withAttempts(err, attempts)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		utils.PerfLog(time.Since(start).Milliseconds(), timeout.Milliseconds()+500, fmt.Sprintf("Get raw document from URL %s with resp code %d", url, resp.StatusCode))
-		return nil, &exception.CustomError{
+		return nil, withAttempts(&exception.CustomError{
 			Status:  http.StatusFailedDependency,
 			Code:    exception.FailedToDownloadDocument,
 			Message: exception.FailedToDownloadDocumentMsg,
 			Params:  map[string]interface{}{"code": strconv.Itoa(resp.StatusCode)},
 			Debug:   fmt.Sprintf("unable to get document with type - %s from url %s: incorrect response code: %d", documentType, url, resp.StatusCode),
-		}
+		}, attempts)
 	}
-	defer resp.Body.Close()
 	bytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		utils.PerfLog(time.Since(start).Milliseconds(), timeout.Milliseconds()+500, fmt.Sprintf("Get raw document from URL %s with body read err %s", url, err))
@@ -72,3 +73,12 @@ func GetRawDocumentFromUrl(url, documentType string, timeout time.Duration) ([]b
 	utils.PerfLog(time.Since(start).Milliseconds(), timeout.Milliseconds()+500, fmt.Sprintf("Get raw document from URL %s", url))
 	return bytes, nil
 }
+
+func newDocumentRequest(ctx context.Context, method string, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	AddDocumentCredentials(req)
+	return req, nil
+}
@@ -41,7 +41,7 @@ func (d documentControllerImpl) GetServiceDocument(w http.ResponseWriter, r *htt
 		return
 	}
 
-	content, err := d.documentService.GetDocumentById(namespace, workspaceId, serviceId, fileId)
+	content, err := d.documentService.GetDocumentById(r.Context(), namespace, workspaceId, serviceId, fileId)
 
 	if err != nil {
 		log.Error("Failed to get document by id: ", err.Error())
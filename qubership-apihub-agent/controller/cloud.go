@@ -2,6 +2,8 @@ package controller
 
 import (
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 
 	"github.com/Netcracker/qubership-apihub-agent/exception"
 	"github.com/Netcracker/qubership-apihub-agent/secctx"
@@ -15,15 +17,22 @@ type CloudController interface {
 	StartAllDiscovery_deprecated(w http.ResponseWriter, r *http.Request)
 }
 
-func NewCloudController(cloudService service.CloudService) CloudController {
-	return &cloudControllerImpl{cloudService: cloudService}
+// header of the request forwarded to the leader replica, such request is never forwarded again
+const forwardedToLeaderHeader = "X-Apihub-Agent-Forwarded"
+
+func NewCloudController(cloudService service.CloudService, leaderElector service.LeaderElector) CloudController {
+	return &cloudControllerImpl{cloudService: cloudService, leaderElector: leaderElector}
 }
 
 type cloudControllerImpl struct {
-	cloudService service.CloudService
+	cloudService  service.CloudService
+	leaderElector service.LeaderElector
 }
 
 func (c cloudControllerImpl) ListAllServices_deprecated(w http.ResponseWriter, r *http.Request) {
+	if c.forwardToLeader(w, r) {
+		return
+	}
 	workspaceId := getStringParam(r, "workspaceId")
 	//v1 support
 	if workspaceId == "" {
@@ -34,6 +43,9 @@ func (c cloudControllerImpl) ListAllServices_deprecated(w http.ResponseWriter, r
 }
 
 func (c cloudControllerImpl) StartAllDiscovery_deprecated(w http.ResponseWriter, r *http.Request) {
+	if c.forwardToLeader(w, r) {
+		return
+	}
 	workspaceId := getStringParam(r, "workspaceId")
 	//v1 support
 	if workspaceId == "" {
@@ -54,3 +66,42 @@ func (c cloudControllerImpl) StartAllDiscovery_deprecated(w http.ResponseWriter,
 	}
 	w.WriteHeader(http.StatusAccepted)
 }
+
+// forwardToLeader proxies the request to the leader replica, so all namespaces discovery runs and reports its progress on a single replica.
+// Returns false if the request should be handled by this replica.
+func (c cloudControllerImpl) forwardToLeader(w http.ResponseWriter, r *http.Request) bool {
+	if c.leaderElector.IsLeader() || r.Header.Get(forwardedToLeaderHeader) != "" {
+		return false
+	}
+	leaderAddress := c.leaderElector.GetLeaderAddress()
+	if leaderAddress == "" {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusServiceUnavailable,
+			Message: "Leader replica is not elected yet",
+		})
+		return true
+	}
+	target, err := url.Parse(leaderAddress)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusInternalServerError,
+			Code:    exception.InvalidURL,
+			Message: exception.InvalidURLMsg,
+			Params:  map[string]interface{}{"url": leaderAddress},
+			Debug:   err.Error(),
+		})
+		return true
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Errorf("Failed to forward request %s to the leader replica %s: %s", r.URL.Path, leaderAddress, err)
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadGateway,
+			Message: "Failed to forward the request to the leader replica",
+			Debug:   err.Error(),
+		})
+	}
+	r.Header.Set(forwardedToLeaderHeader, "true")
+	proxy.ServeHTTP(w, r)
+	return true
+}
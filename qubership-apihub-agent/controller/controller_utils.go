@@ -45,14 +45,18 @@ func respondWithError(w http.ResponseWriter, msg string, err error) {
 }
 
 func getFailOnErrorQueryParam(r *http.Request) (bool, *exception.CustomError) {
-	if r.URL.Query().Get("failOnError") != "" {
-		val, err := strconv.ParseBool(r.URL.Query().Get("failOnError"))
+	return getBoolQueryParam(r, "failOnError")
+}
+
+func getBoolQueryParam(r *http.Request, name string) (bool, *exception.CustomError) {
+	if r.URL.Query().Get(name) != "" {
+		val, err := strconv.ParseBool(r.URL.Query().Get(name))
 		if err != nil {
 			return false, &exception.CustomError{
 				Status:  http.StatusBadRequest,
 				Code:    exception.IncorrectParamType,
 				Message: exception.IncorrectParamTypeMsg,
-				Params:  map[string]interface{}{"param": "failOnError", "type": "bool"},
+				Params:  map[string]interface{}{"param": name, "type": "bool"},
 				Debug:   err.Error(),
 			}
 		}
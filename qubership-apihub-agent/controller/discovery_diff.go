@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Netcracker/qubership-apihub-agent/exception"
+	"github.com/Netcracker/qubership-apihub-agent/service"
+)
+
+type DiscoveryDiffController interface {
+	GetDiscoveryDiff(w http.ResponseWriter, r *http.Request)
+	CompareNamespaces(w http.ResponseWriter, r *http.Request)
+	CompareBlueGreenVersions(w http.ResponseWriter, r *http.Request)
+}
+
+func NewDiscoveryDiffController(discoveryDiffService service.DiscoveryDiffService) DiscoveryDiffController {
+	return discoveryDiffControllerImpl{discoveryDiffService: discoveryDiffService}
+}
+
+type discoveryDiffControllerImpl struct {
+	discoveryDiffService service.DiscoveryDiffService
+}
+
+func (d discoveryDiffControllerImpl) GetDiscoveryDiff(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "name")
+	workspaceId := getStringParam(r, "workspaceId")
+	fromJobId := r.URL.Query().Get("fromJobId")
+	toJobId := r.URL.Query().Get("toJobId")
+
+	diff, err := d.discoveryDiffService.GetDiscoveryDiff(namespace, workspaceId, fromJobId, toJobId)
+	if err != nil {
+		respondWithError(w, "Failed to compare discovery results", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, diff)
+}
+
+func (d discoveryDiffControllerImpl) CompareNamespaces(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "name")
+	workspaceId := getStringParam(r, "workspaceId")
+	targetNamespace := r.URL.Query().Get("targetNamespace")
+	if targetNamespace == "" {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamMissing,
+			Message: exception.RequiredParamMissingMsg,
+			Params:  map[string]interface{}{"param": "targetNamespace"},
+		})
+		return
+	}
+
+	comparison, err := d.discoveryDiffService.CompareNamespaces(workspaceId, namespace, targetNamespace)
+	if err != nil {
+		respondWithError(w, "Failed to compare namespaces", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, comparison)
+}
+
+func (d discoveryDiffControllerImpl) CompareBlueGreenVersions(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "name")
+	workspaceId := getStringParam(r, "workspaceId")
+	serviceName := r.URL.Query().Get("serviceName")
+	if serviceName == "" {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamMissing,
+			Message: exception.RequiredParamMissingMsg,
+			Params:  map[string]interface{}{"param": "serviceName"},
+		})
+		return
+	}
+	serviceId := r.URL.Query().Get("serviceId")
+	targetServiceId := r.URL.Query().Get("targetServiceId")
+
+	comparison, err := d.discoveryDiffService.CompareBlueGreenVersions(namespace, workspaceId, serviceName, serviceId, targetServiceId)
+	if err != nil {
+		respondWithError(w, "Failed to compare blue-green versions", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, comparison)
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Netcracker/qubership-apihub-agent/exception"
+	"github.com/Netcracker/qubership-apihub-agent/service"
+	"github.com/Netcracker/qubership-apihub-agent/view"
+)
+
+const defaultDiscoveryJobsLimit = 100
+
+type DiscoveryJobController interface {
+	ListDiscoveryJobs(w http.ResponseWriter, r *http.Request)
+	GetDiscoveryJob(w http.ResponseWriter, r *http.Request)
+}
+
+func NewDiscoveryJobController(discoveryJobCache service.DiscoveryJobCache) DiscoveryJobController {
+	return discoveryJobControllerImpl{discoveryJobCache: discoveryJobCache}
+}
+
+type discoveryJobControllerImpl struct {
+	discoveryJobCache service.DiscoveryJobCache
+}
+
+func (d discoveryJobControllerImpl) ListDiscoveryJobs(w http.ResponseWriter, r *http.Request) {
+	limit := defaultDiscoveryJobsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			RespondWithCustomError(w, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.IncorrectParamType,
+				Message: exception.IncorrectParamTypeMsg,
+				Params:  map[string]interface{}{"param": "limit", "type": "positive int"},
+			})
+			return
+		}
+	}
+	namespace := r.URL.Query().Get("namespace")
+	workspaceId := r.URL.Query().Get("workspaceId")
+
+	jobs := d.discoveryJobCache.ListJobs(namespace, workspaceId, limit)
+	respondWithJson(w, http.StatusOK, view.DiscoveryJobsResponse{Jobs: jobs})
+}
+
+func (d discoveryJobControllerImpl) GetDiscoveryJob(w http.ResponseWriter, r *http.Request) {
+	jobId := getStringParam(r, "jobId")
+
+	job := d.discoveryJobCache.GetJob(jobId)
+	if job == nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.DiscoveryJobNotFound,
+			Message: exception.DiscoveryJobNotFoundMsg,
+			Params:  map[string]interface{}{"jobId": jobId},
+		})
+		return
+	}
+	respondWithJson(w, http.StatusOK, job)
+}
@@ -13,17 +13,28 @@ type NamespaceController interface {
 	ListNamespaces(w http.ResponseWriter, r *http.Request)
 }
 
-func NewNamespaceController(namespaceListCache service.NamespaceListCache) NamespaceController {
-	return namespaceControllerImpl{namespaceListCache: namespaceListCache}
+func NewNamespaceController(namespaceListCache service.NamespaceListCache, listService service.ListService) NamespaceController {
+	return namespaceControllerImpl{namespaceListCache: namespaceListCache, listService: listService}
 }
 
 type namespaceControllerImpl struct {
 	namespaceListCache service.NamespaceListCache
+	listService        service.ListService
 }
 
 func (n namespaceControllerImpl) ListNamespaces(w http.ResponseWriter, r *http.Request) {
+	withMetadata, paramErr := getBoolQueryParam(r, "withMetadata")
+	if paramErr != nil {
+		respondWithError(w, "failed to parse withMetadata param", paramErr)
+		return
+	}
+	withServiceCount, paramErr := getBoolQueryParam(r, "withServiceCount")
+	if paramErr != nil {
+		respondWithError(w, "failed to parse withServiceCount param", paramErr)
+		return
+	}
 
-	nss, err := n.namespaceListCache.ListNamespaces()
+	items, err := n.namespaceListCache.ListNamespaceItems()
 	if err != nil {
 		log.Error("Failed to list namespaces: ", err.Error())
 		if customError, ok := err.(*exception.CustomError); ok {
@@ -37,6 +48,27 @@ func (n namespaceControllerImpl) ListNamespaces(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	nss := make([]string, 0, len(items))
+	for _, item := range items {
+		nss = append(nss, item.Name)
+	}
 	resp := view.NamespacesListResponse{Namespaces: nss, CloudName: n.namespaceListCache.GetCloudName()}
+	if withMetadata || withServiceCount {
+		var serviceCounts map[string]int
+		if withServiceCount {
+			serviceCounts = n.listService.CountServices(r.Context(), nss)
+		}
+		resp.Items = make([]view.NamespaceItem, 0, len(items))
+		for _, item := range items {
+			if !withMetadata {
+				item.Labels = nil
+				item.Annotations = nil
+			}
+			if count, ok := serviceCounts[item.Name]; ok {
+				item.ServiceCount = &count
+			}
+			resp.Items = append(resp.Items, item)
+		}
+	}
 	respondWithJson(w, http.StatusOK, resp)
 }
@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Netcracker/qubership-apihub-agent/exception"
 	"github.com/Netcracker/qubership-apihub-agent/secctx"
@@ -10,10 +13,16 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const discoveryEventsHeartbeatInterval = time.Second * 15
+
 type ServiceController interface {
 	ListServices_deprecated(w http.ResponseWriter, r *http.Request)
 	ListServices(w http.ResponseWriter, r *http.Request)
 	StartDiscovery(w http.ResponseWriter, r *http.Request)
+	CancelDiscovery(w http.ResponseWriter, r *http.Request)
+	RediscoverService(w http.ResponseWriter, r *http.Request)
+	GetServiceDiscoveryPlan(w http.ResponseWriter, r *http.Request)
+	StreamDiscoveryEvents(w http.ResponseWriter, r *http.Request)
 	ListServiceNames(w http.ResponseWriter, r *http.Request)
 	ListServiceItems(w http.ResponseWriter, r *http.Request)
 }
@@ -56,7 +65,12 @@ func (s serviceControllerImpl) ListServices(w http.ResponseWriter, r *http.Reque
 		workspaceId = view.DefaultWorkspaceId
 	}
 	services, status, details := s.serviceListCache.GetServicesList(namespace, workspaceId)
-	respondWithJson(w, http.StatusOK, view.ServiceListResponse{Services: services, Status: status, Debug: details})
+	respondWithJson(w, http.StatusOK, view.ServiceListResponse{
+		Services:        services,
+		Status:          status,
+		Debug:           details,
+		RemovedServices: s.serviceListCache.GetRemovedServices(namespace, workspaceId),
+	})
 }
 
 func (s serviceControllerImpl) StartDiscovery(w http.ResponseWriter, r *http.Request) {
@@ -72,8 +86,13 @@ func (s serviceControllerImpl) StartDiscovery(w http.ResponseWriter, r *http.Req
 		respondWithError(w, "failed to parse failOnError param", paramErr)
 		return
 	}
+	waitForReady, paramErr := getBoolQueryParam(r, "waitForReady")
+	if paramErr != nil {
+		respondWithError(w, "failed to parse waitForReady param", paramErr)
+		return
+	}
 
-	err := s.discoveryService.StartDiscovery(secctx.Create(r), namespace, workspaceId, failOnError)
+	jobId, err := s.discoveryService.StartDiscovery(secctx.Create(r), namespace, workspaceId, failOnError, waitForReady)
 	if err != nil {
 		log.Error("Failed to start discovery process: ", err.Error())
 		if customError, ok := err.(*exception.CustomError); ok {
@@ -86,7 +105,109 @@ func (s serviceControllerImpl) StartDiscovery(w http.ResponseWriter, r *http.Req
 		}
 		return
 	}
-	w.WriteHeader(http.StatusAccepted)
+	respondWithJson(w, http.StatusAccepted, view.DiscoveryStartResponse{JobId: jobId})
+}
+
+func (s serviceControllerImpl) CancelDiscovery(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "name")
+	workspaceId := getStringParam(r, "workspaceId")
+	if workspaceId == "" {
+		workspaceId = view.DefaultWorkspaceId
+	}
+
+	err := s.discoveryService.CancelDiscovery(namespace, workspaceId)
+	if err != nil {
+		respondWithError(w, "Failed to cancel discovery process", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s serviceControllerImpl) RediscoverService(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "name")
+	workspaceId := getStringParam(r, "workspaceId")
+	serviceId := getStringParam(r, "serviceId")
+
+	result, err := s.discoveryService.RediscoverService(r.Context(), secctx.Create(r), namespace, workspaceId, serviceId)
+	if err != nil {
+		respondWithError(w, "Failed to rediscover service", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, result)
+}
+
+func (s serviceControllerImpl) GetServiceDiscoveryPlan(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "name")
+	serviceId := getStringParam(r, "serviceId")
+
+	plan, err := s.discoveryService.GetServiceDiscoveryPlan(namespace, serviceId)
+	if err != nil {
+		respondWithError(w, "Failed to get service discovery plan", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, plan)
+}
+
+// StreamDiscoveryEvents streams discovery progress as server-sent events: already discovered services first, then each newly discovered service and the status change.
+// Stream is closed after the terminal status event.
+func (s serviceControllerImpl) StreamDiscoveryEvents(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "name")
+	workspaceId := getStringParam(r, "workspaceId")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusInternalServerError,
+			Message: "Streaming is not supported",
+		})
+		return
+	}
+
+	events, unsubscribe := s.serviceListCache.SubscribeToDiscovery(namespace, workspaceId)
+	defer unsubscribe()
+
+	// discovery may take longer than the server write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(discoveryEventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, opened := <-events:
+			if !opened {
+				return
+			}
+			var data interface{}
+			if event.Type == view.DiscoveryEventService {
+				data = event.Service
+			} else {
+				data = event.Status
+			}
+			payload, err := json.Marshal(data)
+			if err != nil {
+				log.Errorf("Failed to serialize discovery event: %s", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
 }
 
 func (s serviceControllerImpl) ListServiceNames(w http.ResponseWriter, r *http.Request) {
@@ -6,12 +6,51 @@ const IncorrectParamTypeMsg = "$param parameter should be $type"
 const InvalidURLEscape = "6"
 const InvalidURLEscapeMsg = "Failed to unescape parameter $param"
 
+const RequiredParamMissing = "7"
+const RequiredParamMissingMsg = "Required parameter $param is missing"
+
 const NamespaceDoesntExist = "100"
 const NamespaceDoesntExistMsg = "Namespace $namespace doesn't exist"
 
 const RouteDoesntExist = "101"
 const RouteDoesntExistMsg = "Route $route doesn't exist"
 
+const DiscoveryNotRunning = "102"
+const DiscoveryNotRunningMsg = "Discovery for namespace $namespace and workspace $workspaceId is not running"
+
+const DiscoveryIsRunning = "103"
+const DiscoveryIsRunningMsg = "Discovery for namespace $namespace and workspace $workspaceId is running"
+
+const NamespaceNotDiscovered = "104"
+const NamespaceNotDiscoveredMsg = "Namespace $namespace is not discovered for workspace $workspaceId"
+
+const ServiceExcludedFromDiscovery = "105"
+const ServiceExcludedFromDiscoveryMsg = "Service $service in namespace $namespace is excluded from discovery"
+
+const DiscoveryJobNotFound = "106"
+const DiscoveryJobNotFoundMsg = "Discovery job $jobId not found"
+
+const DiscoveryJobNotComplete = "107"
+const DiscoveryJobNotCompleteMsg = "Discovery job $jobId is not complete"
+
+const NoPreviousDiscovery = "108"
+const NoPreviousDiscoveryMsg = "There's no previous complete discovery of namespace $namespace for workspace $workspaceId to compare with"
+
+const BlueGreenVersionsNotFound = "109"
+const BlueGreenVersionsNotFoundMsg = "Blue-green versions of service $service are not found in discovery results of namespace $namespace"
+
+const BlueGreenVersionNotFound = "110"
+const BlueGreenVersionNotFoundMsg = "Service $serviceId is not a blue-green version of service $service in namespace $namespace"
+
+const ServiceNotDiscovered = "111"
+const ServiceNotDiscoveredMsg = "Service $serviceId had no ready pods, its documents are not discovered"
+
+const DiscoverySecretNotAvailable = "112"
+const DiscoverySecretNotAvailableMsg = "Secret $secret with credentials of service $serviceId in namespace $namespace is not available"
+
+const DiscoveryNotComplete = "113"
+const DiscoveryNotCompleteMsg = "Discovery of namespace $namespace for workspace $workspaceId is not complete, its status is $status"
+
 const NoApihubAccess = "200"
 const NoApihubAccessMsg = "No access to Apihub with code: $code. Not sufficient rights or incorrect agent configuration(api-key)."
 
@@ -3,9 +3,11 @@ package main
 import (
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 	"time"
 
@@ -91,27 +93,88 @@ func main() {
 	apihubClient := client.NewApihubClient(systemInfoService.GetApihubUrl(), systemInfoService.GetAccessToken(), systemInfoService.GetCloudName())
 	agentsBackendClient := client.NewAgentsBackendClient(systemInfoService.GetApihubUrl(), systemInfoService.GetAccessToken())
 
+	utils.SetDiscoveryConcurrencyLimits(systemInfoService.GetDiscoveryMaxConcurrentRequests(), systemInfoService.GetDiscoveryMaxConcurrentRequestsPerService())
+	if err := utils.SetDiscoveryTlsConfig(systemInfoService.GetDiscoveryCaBundlePath(), systemInfoService.GetDiscoveryClientCertPath(), systemInfoService.GetDiscoveryClientKeyPath(),
+		systemInfoService.GetDiscoveryTlsInsecure()); err != nil {
+		panic("Failed to configure discovery TLS: " + err.Error())
+	}
+	client.SetDocumentRetryPolicy(systemInfoService.GetDiscoveryMaxRetries(), systemInfoService.GetDiscoveryRetryBackoff(), systemInfoService.GetDiscoveryRetryMaxBackoff())
+
+	listenAddr := os.Getenv("LISTEN_ADDRESS")
+	if listenAddr == "" {
+		listenAddr = ":8080"
+	}
+
 	disablingSerivce := service.NewDisablingService()
-	namespaceListCache := service.NewNamespaceListCache(systemInfoService.GetCloudName(), paasCl, systemInfoService.GetNamespacesCacheTTL())
-	serviceListCache := service.NewServiceListCache(systemInfoService.GetServicesCacheTTL())
+	namespaceFilter, err := service.NewNamespaceFilter(systemInfoService.GetIncludeNamespaces(), systemInfoService.GetExcludeNamespaces(),
+		systemInfoService.GetIncludeNamespaceLabels(), systemInfoService.GetExcludeNamespaceLabels())
+	if err != nil {
+		panic("Failed to configure namespace filter: " + err.Error())
+	}
+	namespaceListCache := service.NewNamespaceListCache(systemInfoService.GetCloudName(), paasCl, systemInfoService.GetNamespacesCacheTTL(), namespaceFilter)
+	hostname, _ := os.Hostname()
+	replicaId := fmt.Sprintf("%s_%d", hostname, os.Getpid())
+	var serviceListCache service.ServiceListCache
+	discoveryJobCache := service.NewDiscoveryJobCache()
+	if redisUrl := systemInfoService.GetServicesCacheRedisUrl(); redisUrl != "" {
+		keyPrefix := fmt.Sprintf("apihub-agent:%s:%s:", systemInfoService.GetCloudName(), systemInfoService.GetAgentNamespace())
+		serviceListCache, err = service.NewRedisServiceListCache(systemInfoService.GetServicesCacheTTL(), redisUrl, keyPrefix, replicaId)
+		if err != nil {
+			panic("Failed to create services cache: " + err.Error())
+		}
+		discoveryJobCache, err = service.NewRedisDiscoveryJobCache(redisUrl, keyPrefix, replicaId)
+		if err != nil {
+			panic("Failed to create discovery jobs cache: " + err.Error())
+		}
+	} else if storePath := systemInfoService.GetServicesCacheStorePath(); storePath != "" {
+		serviceListCache, err = service.NewPersistentServiceListCache(systemInfoService.GetServicesCacheTTL(), storePath)
+		if err != nil {
+			panic("Failed to create services cache: " + err.Error())
+		}
+	} else {
+		serviceListCache = service.NewServiceListCache(systemInfoService.GetServicesCacheTTL())
+	}
+	labelFilter, err := service.NewLabelFilter(systemInfoService.GetIncludeLabels(), systemInfoService.GetExcludeLabels())
+	if err != nil {
+		panic("Failed to configure discovery label filter: " + err.Error())
+	}
 	documentsDiscoveryService := service.NewDocumentsDiscoveryService(systemInfoService.GetDiscoveryTimeout())
-	discoveryService := service.NewDiscoveryService(systemInfoService.GetCloudName(), systemInfoService.GetAgentNamespace(), systemInfoService.GetApihubUrl(), systemInfoService.GetExcludeLabels(), systemInfoService.GetGroupingLabels(), namespaceListCache, serviceListCache,
-		paasCl, documentsDiscoveryService, apihubClient)
-	documentService := service.NewDocumentService(serviceListCache, systemInfoService.GetDiscoveryTimeout())
+	discoveryService := service.NewDiscoveryService(systemInfoService.GetCloudName(), systemInfoService.GetAgentNamespace(), systemInfoService.GetApihubUrl(), labelFilter, systemInfoService.GetGroupingLabels(), namespaceListCache, serviceListCache,
+		discoveryJobCache, paasCl, documentsDiscoveryService, apihubClient, systemInfoService.GetDiscoveryWatchEnabled(), systemInfoService.GetDiscoveryReadinessTimeout(),
+		systemInfoService.GetDiscoverySpecDriftCheckEnabled(), systemInfoService.GetDiscoveryTimeout())
+	documentService := service.NewDocumentService(serviceListCache, systemInfoService.GetDiscoveryTimeout(), paasCl)
 	regService := service.NewRegistrationService(systemInfoService.GetCloudName(), systemInfoService.GetAgentNamespace(), systemInfoService.GetAgentUrl(),
 		systemInfoService.GetBackendVersion(), systemInfoService.GetAgentName(), apihubClient, agentsBackendClient, disablingSerivce)
-	listService := service.NewListService(systemInfoService.GetCloudName(), systemInfoService.GetAgentNamespace(), systemInfoService.GetExcludeLabels(), systemInfoService.GetGroupingLabels(), paasCl)
-	cloudService := service.NewCloudService(discoveryService, serviceListCache, namespaceListCache)
+	listService := service.NewListService(systemInfoService.GetCloudName(), systemInfoService.GetAgentNamespace(), labelFilter, systemInfoService.GetGroupingLabels(), paasCl, systemInfoService.GetNamespacesCacheTTL())
+	// all namespaces and scheduled discoveries share the limit of namespaces discovered at once
+	namespaceLimiter := utils.NewConcurrencyLimiter(systemInfoService.GetDiscoveryMaxParallelNamespaces(), 0)
+	cloudService := service.NewCloudService(discoveryService, serviceListCache, namespaceListCache, namespaceLimiter)
 	routesService := service.NewRoutesService(paasCl)
+	discoveryDiffService := service.NewDiscoveryDiffService(discoveryJobCache, serviceListCache)
+	podIp := systemInfoService.GetPodIp()
+	if podIp == "" {
+		podIp = "localhost"
+	}
+	_, listenPort, _ := net.SplitHostPort(listenAddr)
+	leaderLockFile := systemInfoService.GetLeaderElectionLockFile()
+	if leaderLockFile == "" && stubPm != "" {
+		// ConfigMap can't be used as the lock without Kubernetes
+		leaderLockFile = filepath.Join(os.TempDir(), "qubership-apihub-agent-leader.json")
+	}
+	leaderElector := service.NewLeaderElector(systemInfoService.GetLeaderElectionEnabled(), paasCl, systemInfoService.GetAgentNamespace(), leaderLockFile,
+		replicaId, "http://"+net.JoinHostPort(podIp, listenPort))
+	discoveryScheduler := service.NewDiscoveryScheduler(systemInfoService.GetDiscoverySchedules(), discoveryService, serviceListCache, namespaceListCache, leaderElector, namespaceLimiter)
 
-	namespaceController := controller.NewNamespaceController(namespaceListCache)
+	namespaceController := controller.NewNamespaceController(namespaceListCache, listService)
 	serviceController := controller.NewServiceController(serviceListCache, discoveryService, listService)
 	documentController := controller.NewDocumentController(documentService)
 	serviceProxyController := controller.NewServiceProxyController(discoveryService)
 	apiDocsController := controller.NewApiDocsController(basePath)
-	cloudController := controller.NewCloudController(cloudService)
+	cloudController := controller.NewCloudController(cloudService, leaderElector)
 	routesController := controller.NewRoutesController(routesService)
 	logsController := controller.NewLogsController()
+	discoveryJobController := controller.NewDiscoveryJobController(discoveryJobCache)
+	discoveryDiffController := controller.NewDiscoveryDiffController(discoveryDiffService)
 
 	disablingMiddleware := controller.NewDisabledServicesMiddleware(disablingSerivce)
 	r := mux.NewRouter().SkipClean(true).UseEncodedPath()
@@ -130,9 +193,18 @@ func main() {
 
 	r.HandleFunc("/api/v2/namespaces/{name}/workspaces/{workspaceId}/services", security.Secure(serviceController.ListServices_deprecated)).Methods(http.MethodGet) //deprecated
 	r.HandleFunc("/api/v2/namespaces/{name}/workspaces/{workspaceId}/discover", security.Secure(serviceController.StartDiscovery)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/namespaces/{name}/workspaces/{workspaceId}/discover", security.Secure(serviceController.CancelDiscovery)).Methods(http.MethodDelete)
 	r.HandleFunc("/api/v2/namespaces/{name}/workspaces/{workspaceId}/services/{serviceId}/specs/{fileId}", security.Secure(documentController.GetServiceDocument)).Methods(http.MethodGet)
 
 	r.HandleFunc("/api/v3/namespaces/{name}/workspaces/{workspaceId}/services", security.Secure(serviceController.ListServices)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v3/namespaces/{name}/workspaces/{workspaceId}/discover/events", security.Secure(serviceController.StreamDiscoveryEvents)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v3/namespaces/{name}/workspaces/{workspaceId}/services/{serviceId}/discover", security.Secure(serviceController.RediscoverService)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v3/namespaces/{name}/workspaces/{workspaceId}/discovery-diff", security.Secure(discoveryDiffController.GetDiscoveryDiff)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v3/namespaces/{name}/workspaces/{workspaceId}/namespace-comparison", security.Secure(discoveryDiffController.CompareNamespaces)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v3/namespaces/{name}/workspaces/{workspaceId}/blue-green-comparison", security.Secure(discoveryDiffController.CompareBlueGreenVersions)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v3/namespaces/{name}/services/{serviceId}/discovery-plan", security.Secure(serviceController.GetServiceDiscoveryPlan)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v3/discovery-jobs", security.Secure(discoveryJobController.ListDiscoveryJobs)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v3/discovery-jobs/{jobId}", security.Secure(discoveryJobController.GetDiscoveryJob)).Methods(http.MethodGet)
 
 	//deprecated
 	r.HandleFunc("/api/v1/discover", security.Secure(cloudController.StartAllDiscovery_deprecated)).Methods(http.MethodPost)
@@ -201,10 +273,14 @@ func main() {
 
 	regService.RunAgentRegistrationProcess()
 
-	listenAddr := os.Getenv("LISTEN_ADDRESS")
-	if listenAddr == "" {
-		listenAddr = ":8080"
+	leaderElector.RunLeaderElection()
+
+	err = discoveryScheduler.RunDiscoveryScheduler()
+	if err != nil {
+		log.Error("Failed to start discovery scheduler: " + err.Error())
+		panic("Failed to start discovery scheduler: " + err.Error())
 	}
+
 	log.Infof("Listen addr = %s", listenAddr)
 
 	var corsOptions []handlers.CORSOption
@@ -215,7 +291,7 @@ func main() {
 	if allowedOrigin != "" {
 		corsOptions = append(corsOptions, handlers.AllowedOrigins([]string{allowedOrigin}))
 	}
-	corsOptions = append(corsOptions, handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "OPTIONS"}))
+	corsOptions = append(corsOptions, handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"}))
 
 	srv := &http.Server{
 		Handler:      handlers.CompressHandler(handlers.CORS(corsOptions...)(r)),
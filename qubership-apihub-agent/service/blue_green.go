@@ -0,0 +1,91 @@
+package service
+
+import (
+	goctx "context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/entity"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/filter"
+	log "github.com/sirupsen/logrus"
+)
+
+// getBlueGreenVersion returns version suffix of blue-green service name, e.g. v2 for my-service-v2. Returns empty string for other services.
+func getBlueGreenVersion(serviceId string) string {
+	res := bgRegexp.FindStringSubmatch(serviceId)
+	if len(res) < 2 {
+		return ""
+	}
+	return strings.TrimPrefix(serviceId[len(res[1]):], "-")
+}
+
+// getBlueGreenSiblings returns ids of other blue-green versions by service id. Services without other versions are not included.
+func getBlueGreenSiblings(services []entity.Service) map[string][]string {
+	versions := make(map[string][]string)
+	for _, srv := range services {
+		if getBlueGreenVersion(srv.Name) == "" {
+			continue
+		}
+		name := getServiceName(srv.Name, nil)
+		versions[name] = append(versions[name], srv.Name)
+	}
+	siblings := make(map[string][]string)
+	for _, ids := range versions {
+		if len(ids) < 2 {
+			continue
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			for _, siblingId := range ids {
+				if siblingId != id {
+					siblings[id] = append(siblings[id], siblingId)
+				}
+			}
+		}
+	}
+	return siblings
+}
+
+// getBlueGreenSiblingIds lists services of the namespace to find other blue-green versions of the service
+func (d *discoveryServiceImpl) getBlueGreenSiblingIds(ctx goctx.Context, namespace string, serviceId string) []string {
+	if getBlueGreenVersion(serviceId) == "" {
+		return nil
+	}
+	services, err := d.paasClient.GetServiceList(ctx, namespace, filter.Meta{})
+	if err != nil {
+		log.Errorf("Failed to list k8s services in namespace %s to find blue-green versions of %s: %s", namespace, serviceId, err)
+		return nil
+	}
+	return getBlueGreenSiblings(services)[serviceId]
+}
+
+// makeBlueGreen returns nil if the service has no other blue-green versions
+func makeBlueGreen(serviceId string, labels map[string]string, annotations map[string]string, siblingIds []string) *view.BlueGreen {
+	if len(siblingIds) == 0 {
+		return nil
+	}
+	return &view.BlueGreen{
+		Version:    getBlueGreenVersion(serviceId),
+		Active:     isBlueGreenActive(serviceId, labels, annotations),
+		SiblingIds: siblingIds,
+	}
+}
+
+// isBlueGreenActive checks the active marker in the service annotations first, then in the labels of the service and its pods
+func isBlueGreenActive(serviceId string, labels map[string]string, annotations map[string]string) bool {
+	value, exists := annotations[view.CustomK8sBlueGreenActive]
+	if !exists {
+		value, exists = labels[view.CustomK8sBlueGreenActive]
+	}
+	if !exists {
+		return false
+	}
+	active, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warnf("Invalid value '%s' of %s for service %s, expected true or false", value, view.CustomK8sBlueGreenActive, serviceId)
+		return false
+	}
+	return active
+}
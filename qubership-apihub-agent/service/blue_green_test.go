@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBlueGreenSiblings(t *testing.T) {
+	services := []entity.Service{
+		{Metadata: entity.Metadata{Name: "orders-v2"}},
+		{Metadata: entity.Metadata{Name: "orders-v1"}},
+		{Metadata: entity.Metadata{Name: "billing-v1"}},
+		{Metadata: entity.Metadata{Name: "orders"}},
+	}
+	siblings := getBlueGreenSiblings(services)
+	assert.Equal(t, []string{"orders-v2"}, siblings["orders-v1"])
+	assert.Equal(t, []string{"orders-v1"}, siblings["orders-v2"])
+	assert.NotContains(t, siblings, "billing-v1")
+	assert.NotContains(t, siblings, "orders")
+	assert.Equal(t, "v2", getBlueGreenVersion("orders-v2"))
+}
+
+func TestCompareBlueGreenVersions(t *testing.T) {
+	cache := NewServiceListCache(time.Hour)
+	cache.handleDiscoveryStart("ns", "ws", "job")
+	cache.addService("ns", "ws", "job", view.Service{Id: "orders-v1", Name: "orders",
+		BlueGreen: &view.BlueGreen{Version: "v1", Active: true, SiblingIds: []string{"orders-v2"}},
+		Documents: []view.Document{{DocPath: "/a", Hash: "1"}, {DocPath: "/b", Hash: "1"}}})
+	cache.addService("ns", "ws", "job", view.Service{Id: "orders-v2", Name: "orders",
+		BlueGreen: &view.BlueGreen{Version: "v2", SiblingIds: []string{"orders-v1"}},
+		Documents: []view.Document{{DocPath: "/a", Hash: "2"}, {DocPath: "/c", Hash: "1"}}})
+	cache.setResultStatus("ns", "ws", "job", view.StatusComplete, "")
+
+	comparison, err := NewDiscoveryDiffService(nil, cache).CompareBlueGreenVersions("ns", "ws", "orders", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "orders-v1", comparison.ServiceId)
+	assert.Equal(t, "orders-v2", comparison.TargetServiceId)
+	if assert.Len(t, comparison.ChangedDocuments, 1) {
+		assert.Equal(t, "/a", comparison.ChangedDocuments[0].DocPath)
+	}
+	assert.Len(t, comparison.OnlyInServiceDocs, 1)
+	assert.Len(t, comparison.OnlyInTargetDocs, 1)
+
+	_, err = NewDiscoveryDiffService(nil, cache).CompareBlueGreenVersions("ns", "ws", "billing", "", "")
+	assert.Error(t, err)
+	// cancelled discovery may have not reached one of the versions
+	cache.handleDiscoveryStart("ns", "ws", "job2")
+	cache.addService("ns", "ws", "job2", view.Service{Id: "orders-v1", Name: "orders",
+		BlueGreen: &view.BlueGreen{Version: "v1", Active: true, SiblingIds: []string{"orders-v2"}}})
+	cache.setResultStatus("ns", "ws", "job2", view.StatusCancelled, "")
+	_, err = NewDiscoveryDiffService(nil, cache).CompareBlueGreenVersions("ns", "ws", "orders", "", "")
+	assert.Error(t, err)
+}
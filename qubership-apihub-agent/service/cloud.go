@@ -1,6 +1,7 @@
 package service
 
 import (
+	goctx "context"
 	"fmt"
 	"sync"
 	"time"
@@ -16,11 +17,13 @@ type CloudService interface {
 	GetAllServicesList_deprecated(workspaceId string) view.AllServiceListResponse_deprecated
 }
 
-func NewCloudService(discoveryService DiscoveryService, serviceListCache ServiceListCache, namespaceListCache NamespaceListCache) CloudService {
+// NewCloudService creates service which discovers all namespaces in parallel, bounded by namespaceLimiter shared with scheduled discovery
+func NewCloudService(discoveryService DiscoveryService, serviceListCache ServiceListCache, namespaceListCache NamespaceListCache, namespaceLimiter utils.ConcurrencyLimiter) CloudService {
 	return &cloudServiceImpl{
 		discoveryService:   discoveryService,
 		serviceListCache:   serviceListCache,
 		namespaceListCache: namespaceListCache,
+		namespaceLimiter:   namespaceLimiter,
 		status:             view.StatusNone,
 		startMutex:         sync.RWMutex{},
 		started:            time.Time{},
@@ -32,6 +35,7 @@ type cloudServiceImpl struct {
 	discoveryService   DiscoveryService
 	serviceListCache   ServiceListCache
 	namespaceListCache NamespaceListCache
+	namespaceLimiter   utils.ConcurrencyLimiter
 
 	status     view.StatusEnum
 	startMutex sync.RWMutex
@@ -50,21 +54,23 @@ func (c *cloudServiceImpl) StartAllDiscovery_deprecated(ctx secctx.SecurityConte
 	case view.StatusRunning:
 		log.Infof("Do not start all discovery since it's already running")
 		return nil
-	case view.StatusComplete, view.StatusError:
+	case view.StatusComplete, view.StatusError, view.StatusCancelled:
 		log.Infof("Restarting all namespaces discovery")
 	}
 
 	c.status = view.StatusRunning
 	c.started = time.Now()
-	// Unfortunately need to run one by one because multithreading cause not discovered documents due to increased networks delays.
-	// Tried to run all discoveries in parallel and result is incorrect.
+	c.finished = time.Time{}
+	c.errors = nil
+	// Namespaces are discovered in parallel. Network load is bounded by the global discovery requests limiter,
+	// so increased number of namespaces doesn't cause timeouts and not discovered documents.
 	utils.SafeAsync(func() {
-		c.runAllDiscoveryOneByOne(ctx, workspaceId)
+		c.runAllDiscovery(ctx, workspaceId)
 	})
 	return nil
 }
 
-func (c *cloudServiceImpl) runAllDiscoveryOneByOne(ctx secctx.SecurityContext, workspaceId string) {
+func (c *cloudServiceImpl) runAllDiscovery(ctx secctx.SecurityContext, workspaceId string) {
 	namespaces, err := c.namespaceListCache.ListNamespaces()
 	if err != nil {
 		c.startMutex.Lock()
@@ -81,15 +87,30 @@ func (c *cloudServiceImpl) runAllDiscoveryOneByOne(ctx secctx.SecurityContext, w
 	}
 
 	log.Infof("Namespaces to discover: %+v", namespaces)
+	wg := sync.WaitGroup{}
 	for _, ns := range namespaces {
-		err := c.discoveryService.StartDiscovery(ctx, ns, workspaceId, false)
-		if err != nil {
-			log.Errorf("Failed to start discovery for namespace %s: %s", ns, err)
-			c.errors = append(c.errors, fmt.Sprintf("failed to start discovery for namespace %s: %s", ns, err))
-			continue
-		}
-		c.waitForNamespace(ns, workspaceId)
+		namespace := ns
+		wg.Add(1)
+		utils.SafeAsync(func() {
+			defer wg.Done()
+			release, err := c.namespaceLimiter.Acquire(goctx.Background(), namespace)
+			if err != nil {
+				c.addError(fmt.Sprintf("failed to start discovery for namespace %s: %s", namespace, err))
+				return
+			}
+			defer release()
+
+			_, err = c.discoveryService.StartDiscovery(ctx, namespace, workspaceId, false, false)
+			if err != nil {
+				log.Errorf("Failed to start discovery for namespace %s: %s", namespace, err)
+				c.addError(fmt.Sprintf("failed to start discovery for namespace %s: %s", namespace, err))
+				return
+			}
+			c.waitForNamespace(namespace, workspaceId)
+		})
 	}
+	wg.Wait()
+
 	c.startMutex.Lock()
 	defer c.startMutex.Unlock()
 	if len(c.errors) > 0 {
@@ -115,7 +136,14 @@ func (c *cloudServiceImpl) waitForNamespace(ns string, workspaceId string) {
 			}
 			if status == view.StatusError {
 				log.Debugf("waitForNamespace %s error", ns)
-				c.errors = append(c.errors, fmt.Sprintf("failed discovery for namespace %s: %s", ns, details))
+				c.addError(fmt.Sprintf("failed discovery for namespace %s: %s", ns, details))
+				utils.SafeAsync(func() {
+					stop <- struct{}{}
+				})
+			}
+			if status == view.StatusCancelled {
+				log.Debugf("waitForNamespace %s cancelled", ns)
+				c.addError(fmt.Sprintf("discovery for namespace %s was cancelled", ns))
 				utils.SafeAsync(func() {
 					stop <- struct{}{}
 				})
@@ -136,6 +164,12 @@ func (c *cloudServiceImpl) waitForNamespace(ns string, workspaceId string) {
 	}
 }
 
+func (c *cloudServiceImpl) addError(err string) {
+	c.startMutex.Lock()
+	defer c.startMutex.Unlock()
+	c.errors = append(c.errors, err)
+}
+
 func (c *cloudServiceImpl) GetAllServicesList_deprecated(workspaceId string) view.AllServiceListResponse_deprecated {
 	result := view.AllServiceListResponse_deprecated{}
 	result.Status = c.status
@@ -161,8 +195,11 @@ func (c *cloudServiceImpl) GetAllServicesList_deprecated(workspaceId string) vie
 	}
 	result.NamespaceData = namespacesData
 
+	c.startMutex.RLock()
+	errs := c.errors
+	c.startMutex.RUnlock()
 	resultDetails := ""
-	for _, errStr := range c.errors {
+	for _, errStr := range errs {
 		resultDetails += "|" + errStr
 	}
 	if resultDetails != "" {
@@ -172,7 +209,7 @@ func (c *cloudServiceImpl) GetAllServicesList_deprecated(workspaceId string) vie
 	result.TotalNamespaces = len(namespacesData)
 	completed := 0
 	for _, svcs := range namespacesData {
-		if svcs.Status == view.StatusComplete || svcs.Status == view.StatusError {
+		if svcs.Status == view.StatusComplete || svcs.Status == view.StatusError || svcs.Status == view.StatusCancelled {
 			completed += 1
 		}
 		result.TotalServices += len(svcs.Services)
@@ -3,10 +3,12 @@ package service
 import (
 	goctx "context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,7 +25,10 @@ import (
 )
 
 type DiscoveryService interface {
-	StartDiscovery(ctx secctx.SecurityContext, namespace string, workspaceId string, failOnError bool) error
+	StartDiscovery(ctx secctx.SecurityContext, namespace string, workspaceId string, failOnError bool, waitForReady bool) (string, error)
+	CancelDiscovery(namespace string, workspaceId string) error
+	RediscoverService(ctx goctx.Context, secCtx secctx.SecurityContext, namespace string, workspaceId string, serviceId string) (*view.Service, error)
+	GetServiceDiscoveryPlan(namespace string, serviceId string) (*view.DiscoveryPlan, error)
 	GetServiceUrl(namespace string, serviceId string) (string, error)
 }
 
@@ -31,54 +36,101 @@ func NewDiscoveryService(
 	cloudName string,
 	agentNamespace string,
 	apihubUrl string,
-	excludeWithLabels []string,
+	labelFilter LabelFilter,
 	groupingLabels []string,
 	namespaceListCache NamespaceListCache,
 	serviceListCache ServiceListCache,
+	discoveryJobCache DiscoveryJobCache,
 	paasClient service.PlatformService,
 	documentsDiscoveryService DocumentsDiscoveryService,
-	apihubClient client.ApihubClient) DiscoveryService {
+	apihubClient client.ApihubClient,
+	watchEnabled bool,
+	readinessTimeout time.Duration,
+	specDriftCheckEnabled bool,
+	podDocumentTimeout time.Duration) DiscoveryService {
 	groupingLabelsMap := make(map[string]struct{}, len(groupingLabels))
 	for _, label := range groupingLabels {
 		groupingLabelsMap[label] = struct{}{}
 	}
 
-	return &discoveryServiceImpl{
+	d := &discoveryServiceImpl{
 		cloudName:                 cloudName,
 		agentNamespace:            agentNamespace,
 		apihubUrl:                 apihubUrl,
-		excludeWithLabels:         excludeWithLabels,
+		labelFilter:               labelFilter,
 		groupingLabels:            groupingLabelsMap,
 		namespaceListCache:        namespaceListCache,
 		serviceListCache:          serviceListCache,
+		discoveryJobCache:         discoveryJobCache,
 		paasClient:                paasClient,
 		documentsDiscoveryService: documentsDiscoveryService,
-		apihubClient:              apihubClient}
+		apihubClient:              apihubClient,
+		runningDiscoveries:        map[string]*discoveryRun{},
+		watchEnabled:              watchEnabled,
+		namespaceWatches:          map[string]*namespaceWatch{},
+		readinessTimeout:          readinessTimeout,
+		specDriftCheckEnabled:     specDriftCheckEnabled,
+		podDocumentTimeout:        podDocumentTimeout}
+	serviceListCache.onCancelRequested(func(namespace string, workspaceId string, jobId string) {
+		d.cancelRun(namespace, workspaceId, jobId)
+	})
+	return d
 }
 
 type discoveryServiceImpl struct {
-	cloudName         string
-	agentNamespace    string
-	apihubUrl         string
-	excludeWithLabels []string
-	groupingLabels    map[string]struct{}
+	cloudName      string
+	agentNamespace string
+	apihubUrl      string
+	labelFilter    LabelFilter
+	groupingLabels map[string]struct{}
 
 	namespaceListCache NamespaceListCache
 	serviceListCache   ServiceListCache
+	discoveryJobCache  DiscoveryJobCache
 
 	paasClient                service.PlatformService
 	documentsDiscoveryService DocumentsDiscoveryService
 	apihubClient              client.ApihubClient
+
+	runningDiscoveries      map[string]*discoveryRun
+	runningDiscoveriesMutex sync.Mutex
+
+	watchEnabled          bool
+	namespaceWatches      map[string]*namespaceWatch
+	namespaceWatchesMutex sync.Mutex
+
+	readinessTimeout time.Duration
+
+	specDriftCheckEnabled bool
+	podDocumentTimeout    time.Duration
+}
+
+// pods readiness is checked periodically while discovery waits for not ready services
+const readinessPollInterval = time.Second * 10
+
+// notReadyService is k8s service which has no ready pods while its deployment expects them
+type notReadyService struct {
+	srv         entity.Service
+	deployment  *entity.Deployment
+	annotations map[string]string
+}
+
+// discoveryRun holds the cancel function and the job of a single discovery run for namespace and workspace
+type discoveryRun struct {
+	cancel goctx.CancelFunc
+	jobId  string
 }
 
-func (d discoveryServiceImpl) StartDiscovery(ctx secctx.SecurityContext, namespace string, workspaceId string, failOnError bool) error {
+// StartDiscovery starts asynchronous discovery of the namespace.
+// With failOnError the discovery fails if some service has no ready pods. With waitForReady the discovery waits for such services to become ready instead, the services which didn't become ready in time are marked as not ready.
+func (d *discoveryServiceImpl) StartDiscovery(ctx secctx.SecurityContext, namespace string, workspaceId string, failOnError bool, waitForReady bool) (string, error) {
 	exists, err := d.namespaceListCache.NamespaceExists(namespace)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if !exists {
-		return &exception.CustomError{
+		return "", &exception.CustomError{
 			Status:  http.StatusBadRequest,
 			Code:    exception.NamespaceDoesntExist,
 			Message: exception.NamespaceDoesntExistMsg,
@@ -86,17 +138,210 @@ func (d discoveryServiceImpl) StartDiscovery(ctx secctx.SecurityContext, namespa
 		}
 	}
 
-	d.serviceListCache.handleDiscoveryStart(namespace, workspaceId)
+	jobId := d.discoveryJobCache.createJob(namespace, workspaceId, ctx.GetUserId())
+	runCtx, run := d.registerDiscoveryRun(namespace, workspaceId, jobId)
+	d.serviceListCache.handleDiscoveryStart(namespace, workspaceId, jobId)
 	utils.SafeAsync(func() {
-		d.runDiscovery(ctx, namespace, workspaceId, failOnError)
+		defer d.unregisterDiscoveryRun(namespace, workspaceId, run)
+		d.runDiscovery(runCtx, ctx, jobId, namespace, workspaceId, failOnError, waitForReady)
 	})
-	return nil
+	return jobId, nil
+}
+
+// CancelDiscovery cancels the discovery run by this replica. The discovery run by other replica sharing the results is cancelled by that replica asynchronously.
+func (d *discoveryServiceImpl) CancelDiscovery(namespace string, workspaceId string) error {
+	if d.cancelRun(namespace, workspaceId, "") || d.serviceListCache.requestCancel(namespace, workspaceId) {
+		return nil
+	}
+	return &exception.CustomError{
+		Status:  http.StatusNotFound,
+		Code:    exception.DiscoveryNotRunning,
+		Message: exception.DiscoveryNotRunningMsg,
+		Params:  map[string]interface{}{"namespace": namespace, "workspaceId": workspaceId},
+	}
+}
+
+// cancelRun cancels the discovery run by this replica. If jobId is not empty, the run is cancelled only if it belongs to the job. Returns false if there's no such run.
+func (d *discoveryServiceImpl) cancelRun(namespace string, workspaceId string, jobId string) bool {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	d.runningDiscoveriesMutex.Lock()
+	run, exists := d.runningDiscoveries[id]
+	if exists && jobId != "" && run.jobId != jobId {
+		exists = false
+	}
+	if exists {
+		delete(d.runningDiscoveries, id)
+	}
+	d.runningDiscoveriesMutex.Unlock()
+
+	if !exists {
+		return false
+	}
+
+	log.Infof("Cancelling discovery for namespace %s and workspaceId %s", namespace, workspaceId)
+	run.cancel()
+	d.serviceListCache.setResultStatus(namespace, workspaceId, run.jobId, view.StatusCancelled, "discovery was cancelled")
+	d.discoveryJobCache.finishJob(run.jobId, view.StatusCancelled, "discovery was cancelled")
+	return true
+}
+
+// RediscoverService discovers single k8s service and replaces it in the namespace discovery results. Discovery is stopped if ctx is cancelled.
+func (d *discoveryServiceImpl) RediscoverService(ctx goctx.Context, secCtx secctx.SecurityContext, namespace string, workspaceId string, serviceId string) (*view.Service, error) {
+	exists, err := d.namespaceListCache.NamespaceExists(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.NamespaceDoesntExist,
+			Message: exception.NamespaceDoesntExistMsg,
+			Params:  map[string]interface{}{"namespace": namespace},
+		}
+	}
+
+	_, status, _ := d.serviceListCache.GetServicesList(namespace, workspaceId)
+	switch status {
+	case view.StatusNone:
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.NamespaceNotDiscovered,
+			Message: exception.NamespaceNotDiscoveredMsg,
+			Params:  map[string]interface{}{"namespace": namespace, "workspaceId": workspaceId},
+		}
+	case view.StatusRunning:
+		return nil, &exception.CustomError{
+			Status:  http.StatusConflict,
+			Code:    exception.DiscoveryIsRunning,
+			Message: exception.DiscoveryIsRunningMsg,
+			Params:  map[string]interface{}{"namespace": namespace, "workspaceId": workspaceId},
+		}
+	}
+
+	srv, labels, annotations, err := d.getServiceDetails(ctx, namespace, serviceId)
+	if err != nil {
+		return nil, err
+	}
+	if srv == nil {
+		d.serviceListCache.removeService(namespace, workspaceId, serviceId)
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.NamespaceServiceDoesntExist,
+			Message: exception.NamespaceServiceDoesntExistMsg,
+			Params:  map[string]interface{}{"service": serviceId, "namespace": namespace},
+		}
+	}
+	if d.isExcluded(serviceId, labels) {
+		d.serviceListCache.removeService(namespace, workspaceId, serviceId)
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.ServiceExcludedFromDiscovery,
+			Message: exception.ServiceExcludedFromDiscoveryMsg,
+			Params:  map[string]interface{}{"service": serviceId, "namespace": namespace},
+		}
+	}
+
+	log.Infof("Rediscovering service %s in namespace %s for workspaceId %s", serviceId, namespace, workspaceId)
+	result := d.discoverService(ctx, secCtx, namespace, workspaceId, *srv, labels, annotations, d.getBlueGreenSiblingIds(ctx, namespace, serviceId))
+	if result == nil {
+		// the client is gone, partial result must not replace the previous one
+		return nil, fmt.Errorf("rediscovery of service %s is cancelled: %w", serviceId, ctx.Err())
+	}
+	d.serviceListCache.updateService(namespace, workspaceId, *result)
+	return result, nil
+}
+
+// GetServiceDiscoveryPlan returns urls which would be probed by discovery of k8s service. Nothing is requested from the service itself.
+func (d *discoveryServiceImpl) GetServiceDiscoveryPlan(namespace string, serviceId string) (*view.DiscoveryPlan, error) {
+	exists, err := d.namespaceListCache.NamespaceExists(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.NamespaceDoesntExist,
+			Message: exception.NamespaceDoesntExistMsg,
+			Params:  map[string]interface{}{"namespace": namespace},
+		}
+	}
+
+	srv, labels, annotations, err := d.getServiceDetails(goctx.Background(), namespace, serviceId)
+	if err != nil {
+		return nil, err
+	}
+	if srv == nil {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.NamespaceServiceDoesntExist,
+			Message: exception.NamespaceServiceDoesntExistMsg,
+			Params:  map[string]interface{}{"service": serviceId, "namespace": namespace},
+		}
+	}
+
+	resolveExternalHosts(goctx.Background(), []entity.Service{*srv})
+
+	plan := &view.DiscoveryPlan{
+		ServiceId:   serviceId,
+		ServiceName: getServiceName(serviceId, annotations),
+		Namespace:   namespace,
+		BaseUrl:     buildBaseurl(*srv),
+		External:    isExternalService(*srv),
+		Urls:        view.MakeDiscoveryPlanUrls(annotations),
+	}
+	config, configErrors := view.ParseDiscoveryConfig(annotations)
+	if config != nil {
+		plan.Documents = config.Documents
+	}
+	plan.ConfigErrors = configErrors
+	plan.Secret = getDiscoverySecretName(annotations)
+	plan.Excluded, plan.ExcludedByLabel = d.labelFilter.Excluded(labels)
+	for i, port := range getDiscoveryPorts(*srv) {
+		planPort := view.DiscoveryPlanPort{Name: port.Name, Port: port.Port, BaseUrl: buildPortBaseurl(*srv, port)}
+		if i == 0 {
+			plan.Port = &planPort
+		}
+		plan.Ports = append(plan.Ports, planPort)
+	}
+	for i := range plan.Urls {
+		plan.Urls[i].Runners = d.documentsDiscoveryService.GetRunnerNames(plan.Urls[i].Kind)
+	}
+	return plan, nil
+}
+
+// registerDiscoveryRun creates cancellable context for new discovery run. Previous run for the same namespace and workspace (if any) is cancelled since its results are going to be overwritten.
+func (d *discoveryServiceImpl) registerDiscoveryRun(namespace string, workspaceId string, jobId string) (goctx.Context, *discoveryRun) {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+	ctx, cancel := goctx.WithCancel(goctx.Background())
+	run := &discoveryRun{cancel: cancel, jobId: jobId}
+
+	d.runningDiscoveriesMutex.Lock()
+	defer d.runningDiscoveriesMutex.Unlock()
+	if prevRun, exists := d.runningDiscoveries[id]; exists {
+		log.Infof("Cancelling previous discovery for namespace %s and workspaceId %s", namespace, workspaceId)
+		prevRun.cancel()
+	}
+	d.runningDiscoveries[id] = run
+	return ctx, run
+}
+
+func (d *discoveryServiceImpl) unregisterDiscoveryRun(namespace string, workspaceId string, run *discoveryRun) {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	d.runningDiscoveriesMutex.Lock()
+	defer d.runningDiscoveriesMutex.Unlock()
+	if d.runningDiscoveries[id] == run {
+		delete(d.runningDiscoveries, id)
+	}
+	run.cancel()
+	// job is normally finished by the run itself, this is the case of unexpected run interruption
+	d.discoveryJobCache.finishJob(run.jobId, view.StatusError, "discovery was interrupted")
 }
 
-func (d discoveryServiceImpl) runDiscovery(secCtx secctx.SecurityContext, namespace string, workspaceId string, failOnError bool) {
+func (d *discoveryServiceImpl) runDiscovery(ctx goctx.Context, secCtx secctx.SecurityContext, jobId string, namespace string, workspaceId string, failOnError bool, waitForReady bool) {
 	log.Infof("Starting discovery for namespace %s", namespace)
 	start := time.Now()
-	ctx := goctx.Background()
 
 	wg := sync.WaitGroup{}
 
@@ -127,25 +372,48 @@ func (d discoveryServiceImpl) runDiscovery(secCtx secctx.SecurityContext, namesp
 	wg.Wait()
 
 	if svcErr != nil {
-		d.serviceListCache.setResultStatus(namespace, workspaceId, view.StatusError, svcErr.Error())
+		d.setResultStatus(ctx, jobId, namespace, workspaceId, view.StatusError, svcErr.Error())
 		log.Errorf("Failed to list k8s services in namespace %s: %s", namespace, svcErr.Error())
 		return
 	}
 
 	if podsErr != nil {
-		d.serviceListCache.setResultStatus(namespace, workspaceId, view.StatusError, podsErr.Error())
+		d.setResultStatus(ctx, jobId, namespace, workspaceId, view.StatusError, podsErr.Error())
 		log.Errorf("Failed to list k8s pods in namespace %s: %s", namespace, podsErr.Error())
 		return
 	}
 
 	if deploymentsErr != nil {
-		d.serviceListCache.setResultStatus(namespace, workspaceId, view.StatusError, podsErr.Error())
-		log.Errorf("Failed to list k8s deployments in namespace %s: %s", namespace, podsErr.Error())
+		d.setResultStatus(ctx, jobId, namespace, workspaceId, view.StatusError, deploymentsErr.Error())
+		log.Errorf("Failed to list k8s deployments in namespace %s: %s", namespace, deploymentsErr.Error())
 		return
 	}
 
-	agentId := utils.MakeAgentId(d.cloudName, d.agentNamespace)
+	resolveExternalHosts(ctx, services)
+	bgSiblings := getBlueGreenSiblings(services)
+	discover := func(srv entity.Service, labels map[string]string, annotations map[string]string) {
+		wg.Add(1)
+		utils.SafeAsync(func() {
+			defer wg.Done()
+			srvStart := time.Now()
+			srvToAdd := d.discoverService(ctx, secCtx, namespace, workspaceId, srv, labels, annotations, bgSiblings[srv.Name])
+			if srvToAdd == nil {
+				// discovery is cancelled, results are not needed anymore
+				return
+			}
+			d.serviceListCache.addService(namespace, workspaceId, jobId, *srvToAdd)
+			d.discoveryJobCache.addServiceResult(jobId, view.DiscoveryJobService{
+				Id:             srvToAdd.Id,
+				Name:           srvToAdd.Name,
+				DurationMs:     time.Since(srvStart).Milliseconds(),
+				DocumentsCount: len(srvToAdd.Documents),
+				Error:          srvToAdd.Error,
+				Documents:      view.MakeDiscoveryJobDocuments(srvToAdd.Documents),
+			})
+		})
+	}
 
+	var notReadyServices []notReadyService
 	for _, srv := range services {
 		log.Infof("Getting pods for service: %s", srv.Name)
 		servicePods := getPodsForSelector(pods, srv.Spec.Selector)
@@ -157,158 +425,460 @@ func (d discoveryServiceImpl) runDiscovery(secCtx secctx.SecurityContext, namesp
 		log.Debugf("Deployment for service %s: %+v", srv.Name, deployment)
 
 		// apply skip list for full list of labels
-		exclude := false
-		for _, label := range d.excludeWithLabels {
-			if _, ok := labels[label]; ok {
-				log.Infof("Service %s is excluded from discovery", srv.Name)
-				exclude = true
-				break
-			}
+		if d.isExcluded(srv.Name, labels) {
+			continue
 		}
-		if exclude {
+
+		if waitForReady && !isServiceReady(srv, servicePods, deployment) {
+			log.Infof("Service %s has no ready pods, waiting for it", srv.Name)
+			notReadyServices = append(notReadyServices, notReadyService{srv: srv, deployment: deployment, annotations: annotations})
 			continue
 		}
 
-		containerReady := false
-		for _, svcPod := range servicePods {
-			containerStatuses := svcPod.Status.ContainerStatuses
-			for _, containerStatus := range containerStatuses {
-				if containerStatus.Ready {
-					containerReady = true
-					break
-				}
+		if failOnError { // invoke service status check if true
+			if !isServiceReady(srv, servicePods, deployment) {
+				// We expect the service up and running, but have no live and ready pods.
+				// Looks like the namespace is in deployment/restart phase.
+				// In this case discovery result will not be completely correct, so returning the error.
+				errMsg := fmt.Sprintf("no pod is up yet for service: %s", srv.Name)
+				d.setResultStatus(ctx, jobId, namespace, workspaceId, view.StatusError, errMsg)
+				log.Error(errMsg)
+				return
 			}
 		}
 
-		if failOnError { // invoke service status check if true
-			if srv.Spec.Type != "ExternalName" { // ExternalName service do not have pods in local namespace, so the following check is not applicable.
-				// Some deployments may be scaled down intentionally, need to check replicas count
-				if deployment != nil && deployment.Spec.Replicas != nil && *deployment.Spec.Replicas > 0 && !containerReady {
-					// We expect the service up and running, but have no live and ready pods.
-					// Looks like the namespace is in deployment/restart phase.
-					// In this case discovery result will not be completely correct, so returning the error.
-					errMsg := fmt.Sprintf("no pod is up yet for service: %s", srv.Name)
-					d.serviceListCache.setResultStatus(namespace, workspaceId, view.StatusError, errMsg)
-					log.Error(errMsg)
-					return
-				}
+		discover(srv, labels, annotations)
+	}
+
+	if len(notReadyServices) > 0 {
+		notReadyServices = d.waitForReadiness(ctx, namespace, notReadyServices, discover)
+		for _, notReady := range notReadyServices {
+			srvToAdd := d.makeNotReadyService(namespace, notReady)
+			d.serviceListCache.addService(namespace, workspaceId, jobId, srvToAdd)
+			d.discoveryJobCache.addServiceResult(jobId, view.DiscoveryJobService{
+				Id:       srvToAdd.Id,
+				Name:     srvToAdd.Name,
+				Error:    srvToAdd.Error,
+				NotReady: true,
+			})
+		}
+	}
+
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		log.Infof("Discovery for namespace %s was cancelled after %dms", namespace, time.Since(start).Milliseconds())
+		d.discoveryJobCache.finishJob(jobId, view.StatusCancelled, "discovery was cancelled")
+		return
+	}
+
+	log.Infof("Discovery for namespace %s took %dms", namespace, time.Since(start).Milliseconds())
+
+	d.setResultStatus(ctx, jobId, namespace, workspaceId, view.StatusComplete, "")
+
+	if d.watchEnabled {
+		d.watchNamespace(namespace, workspaceId, services, deployments)
+	}
+}
+
+// waitForReadiness polls pods of not ready services until they become ready or readiness timeout expires. Services which became ready are discovered.
+// Returns services which are still not ready.
+func (d *discoveryServiceImpl) waitForReadiness(ctx goctx.Context, namespace string, notReadyServices []notReadyService,
+	discover func(srv entity.Service, labels map[string]string, annotations map[string]string)) []notReadyService {
+	log.Infof("Waiting up to %v for %d not ready services in namespace %s", d.readinessTimeout, len(notReadyServices), namespace)
+	deadline := time.NewTimer(d.readinessTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	for len(notReadyServices) > 0 {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-deadline.C:
+			log.Infof("%d services in namespace %s didn't become ready in %v", len(notReadyServices), namespace, d.readinessTimeout)
+			return notReadyServices
+		case <-ticker.C:
+		}
+		pods, err := d.paasClient.GetPodList(ctx, namespace, filter.Meta{})
+		if err != nil {
+			log.Warnf("Failed to list k8s pods in namespace %s: %s", namespace, err)
+			continue
+		}
+		stillNotReady := make([]notReadyService, 0, len(notReadyServices))
+		for _, notReady := range notReadyServices {
+			servicePods := getPodsForSelector(pods, notReady.srv.Spec.Selector)
+			if !isServiceReady(notReady.srv, servicePods, notReady.deployment) {
+				stillNotReady = append(stillNotReady, notReady)
+				continue
 			}
+			log.Infof("Service %s became ready", notReady.srv.Name)
+			discover(notReady.srv, getAllLabelsForService(notReady.srv, servicePods), notReady.annotations)
 		}
+		notReadyServices = stillNotReady
+	}
+	return notReadyServices
+}
 
-		discoveryUrls := view.MakeDocDiscoveryUrls(annotations)
+// makeNotReadyService makes discovery result for the service which has no ready pods. Documents are not discovered.
+func (d *discoveryServiceImpl) makeNotReadyService(namespace string, notReady notReadyService) view.Service {
+	serviceId := notReady.srv.Name
+	return view.Service{
+		Id:             serviceId,
+		Name:           getServiceName(serviceId, notReady.annotations),
+		Url:            buildBaseurl(notReady.srv),
+		Documents:      []view.Document{},
+		ProxyServerUrl: utils.MakeCustomProxyPath(utils.MakeAgentId(d.cloudName, d.agentNamespace), namespace, serviceId),
+		Error:          fmt.Sprintf("service has no ready pods after waiting for %v", d.readinessTimeout),
+		NotReady:       true,
+	}
+}
 
-		srvTmp := srv
-		wg.Add(1)
+// getServiceDetails returns k8s service with full list of labels and annotations. Returns nil service if it doesn't exist.
+func (d *discoveryServiceImpl) getServiceDetails(ctx goctx.Context, namespace string, serviceId string) (*entity.Service, map[string]string, map[string]string, error) {
+	srv, err := d.paasClient.GetService(ctx, serviceId, namespace)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get k8s service %s in namespace %s: %w", serviceId, namespace, err)
+	}
+	if srv == nil {
+		return nil, nil, nil, nil
+	}
 
-		utils.SafeAsync(func() {
-			serviceId := srvTmp.Name
-			serviceName := getServiceName(serviceId, annotations)
-			baseUrl := buildBaseurl(srvTmp)
+	var servicePods []entity.Pod
+	if len(srv.Spec.Selector) > 0 {
+		pods, err := d.paasClient.GetPodList(ctx, namespace, filter.Meta{Labels: srv.Spec.Selector})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to list k8s pods for service %s in namespace %s: %w", serviceId, namespace, err)
+		}
+		servicePods = getPodsForSelector(pods, srv.Spec.Selector)
+	}
+	return srv, getAllLabelsForService(*srv, servicePods), getAllAnnotationsForService(*srv), nil
+}
 
-			var discoveryResult *view.DiscoveryResult
-			var docErr error
+func (d *discoveryServiceImpl) isExcluded(serviceId string, labels map[string]string) bool {
+	if excluded, rule := d.labelFilter.Excluded(labels); excluded {
+		log.Infof("Service %s is excluded from discovery by label rule '%s'", serviceId, rule)
+		return true
+	}
+	return false
+}
 
-			// search for documents and for baseline in parallel
-			srvWg := sync.WaitGroup{}
-			srvWg.Add(2)
+// discoverService searches for documents and baseline of k8s service. Returns nil if discovery is cancelled.
+func (d *discoveryServiceImpl) discoverService(ctx goctx.Context, secCtx secctx.SecurityContext, namespace string, workspaceId string, srv entity.Service, labels map[string]string, annotations map[string]string, bgSiblingIds []string) *view.Service {
+	resolveExternalHosts(ctx, []entity.Service{srv})
+	serviceId := srv.Name
+	serviceName := getServiceName(serviceId, annotations)
+	baseUrl := buildBaseurl(srv)
+	ports := getDiscoveryPorts(srv)
+	discoveryUrls := view.MakeDocDiscoveryUrls(annotations)
 
-			utils.SafeAsync(func() {
-				defer srvWg.Done()
+	secretName := getDiscoverySecretName(annotations)
+	secretErr := ""
+	if secretName != "" {
+		credentials, err := getDocumentCredentials(ctx, d.paasClient, namespace, secretName)
+		if err != nil {
+			log.Errorf("Service %s documents are discovered without credentials: %s", serviceId, err)
+			secretErr = err.Error()
+		} else {
+			ctx = client.WithDocumentCredentials(ctx, credentials)
+		}
+	}
 
-				discoveryResult, docErr = d.documentsDiscoveryService.RetrieveDocuments(baseUrl, serviceName, discoveryUrls)
-				if docErr != nil {
-					log.Errorf("Service %s have errors during discovery: %s", serviceName, docErr)
-				}
-			})
+	var discoveryResult *view.DiscoveryResult
+	var docErr error
 
-			var baselineObj *view.Baseline
+	// search for documents and for baseline in parallel
+	srvWg := sync.WaitGroup{}
+	srvWg.Add(2)
 
-			utils.SafeAsync(func() {
-				defer srvWg.Done()
-				baselinePackage, err := d.apihubClient.GetPackageByServiceName(secCtx, workspaceId, serviceName) // name here, not id!
-				if err != nil {
-					log.Errorf("failed to get baseline for %s: %s", serviceName, err)
-				}
+	utils.SafeAsync(func() {
+		defer srvWg.Done()
 
-				if baselinePackage != nil {
-					versions := make([]string, 0)
-
-					defaultVersion := baselinePackage.DefaultReleaseVersion
-					versionsResp, err := d.apihubClient.GetVersions(secCtx, baselinePackage.Id, 0, 100)
-					if err != nil {
-						log.Warnf("failed to get baseline %s versions: %s", baselinePackage.Id, err)
-					} else {
-						if versionsResp != nil {
-							for _, v := range versionsResp.Versions {
-								versions = append(versions, v.Version)
-
-								if defaultVersion == "" {
-									defaultVersion = v.Version
-								}
-							}
-						}
-					}
-
-					baselineObj = &view.Baseline{
-						PackageId: baselinePackage.Id,
-						Name:      baselinePackage.Name,
-						Url:       fmt.Sprintf("%s/portal/packages/%s/%s?mode=overview&item=summary", d.apihubUrl, baselinePackage.Id, url.PathEscape(defaultVersion)),
-						Versions:  versions,
-					}
-				}
-			})
+		discoveryResult, docErr = d.retrieveDocumentsFromPorts(ctx, srv, ports, serviceName, discoveryUrls)
+		if docErr != nil {
+			log.Errorf("Service %s have errors during discovery: %s", serviceName, docErr)
+		}
+	})
 
-			srvWg.Wait()
+	var baselineObj *view.Baseline
 
-			labelsToAdd := map[string]string{}
-			for k, v := range labels {
-				if _, ok := d.groupingLabels[k]; ok {
-					labelsToAdd[k] = v
-					continue
-				}
-				if k == xApiKindLabel {
-					labelsToAdd[k] = v
-				}
-			}
+	utils.SafeAsync(func() {
+		defer srvWg.Done()
+		baselineObj = d.getBaseline(ctx, secCtx, workspaceId, serviceName)
+	})
+
+	srvWg.Wait()
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	var specDrift *view.SpecDrift
+	if d.specDriftCheckEnabled && discoveryResult != nil && len(discoveryResult.Documents) > 0 {
+		specDrift = d.checkSpecDrift(ctx, srv, discoveryResult.Documents)
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+
+	labelsToAdd := map[string]string{}
+	for k, v := range labels {
+		if _, ok := d.groupingLabels[k]; ok {
+			labelsToAdd[k] = v
+			continue
+		}
+		if k == xApiKindLabel {
+			labelsToAdd[k] = v
+		}
+	}
 
-			errorStr := ""
-			if docErr != nil {
-				errorStr = docErr.Error()
+	errorStr := ""
+	if docErr != nil {
+		errorStr = docErr.Error()
+	}
+
+	// Build diagnostic info - only include failed calls if no specs found
+	var diagnostic *view.ServiceDiagnostic
+	documents := []view.Document{}
+	if discoveryResult != nil {
+		documents = discoveryResult.Documents
+		if len(discoveryResult.Documents) == 0 && len(discoveryResult.EndpointCalls) > 0 {
+			diagnostic = &view.ServiceDiagnostic{
+				EndpointCalls: discoveryResult.EndpointCalls,
 			}
+		}
+		if len(discoveryResult.RetriedCalls) > 0 {
+			if diagnostic == nil {
+				diagnostic = &view.ServiceDiagnostic{}
+			}
+			diagnostic.RetriedCalls = discoveryResult.RetriedCalls
+		}
+	}
+	if len(discoveryUrls.ConfigErrors) > 0 {
+		if diagnostic == nil {
+			diagnostic = &view.ServiceDiagnostic{}
+		}
+		diagnostic.ConfigErrors = discoveryUrls.ConfigErrors
+	}
+	if secretErr != "" {
+		if diagnostic == nil {
+			diagnostic = &view.ServiceDiagnostic{}
+		}
+		diagnostic.SecretError = secretErr
+	}
+
+	return &view.Service{
+		Id:              serviceId,
+		Name:            serviceName,
+		Url:             baseUrl,
+		Documents:       documents,
+		Baseline:        baselineObj,
+		Labels:          labelsToAdd,
+		ProxyServerUrl:  utils.MakeCustomProxyPath(utils.MakeAgentId(d.cloudName, d.agentNamespace), namespace, serviceId),
+		Error:           errorStr,
+		DiagnosticInfo:  diagnostic,
+		SpecDrift:       specDrift,
+		External:        isExternalService(srv),
+		BlueGreen:       makeBlueGreen(serviceId, labels, annotations, bgSiblingIds),
+		DiscoverySecret: secretName,
+	}
+}
+
+// getBaseline returns the package of the workspace which the service is published to. Returns nil if there's no such package.
+func (d *discoveryServiceImpl) getBaseline(ctx goctx.Context, secCtx secctx.SecurityContext, workspaceId string, serviceName string) *view.Baseline {
+	if ctx.Err() != nil {
+		return nil
+	}
+	baselinePackage, err := d.apihubClient.GetPackageByServiceName(secCtx, workspaceId, serviceName) // name here, not id!
+	if err != nil {
+		log.Errorf("failed to get baseline for %s: %s", serviceName, err)
+	}
+	if baselinePackage == nil {
+		return nil
+	}
 
-			// Build diagnostic info - only include failed calls if no specs found
-			var diagnostic *view.ServiceDiagnostic
-			documents := []view.Document{}
-			if discoveryResult != nil {
-				documents = discoveryResult.Documents
-				if len(discoveryResult.Documents) == 0 && len(discoveryResult.EndpointCalls) > 0 {
-					diagnostic = &view.ServiceDiagnostic{
-						EndpointCalls: discoveryResult.EndpointCalls,
-					}
+	versions := make([]string, 0)
+
+	defaultVersion := baselinePackage.DefaultReleaseVersion
+	versionsResp, err := d.apihubClient.GetVersions(secCtx, baselinePackage.Id, 0, 100)
+	if err != nil {
+		log.Warnf("failed to get baseline %s versions: %s", baselinePackage.Id, err)
+	} else {
+		if versionsResp != nil {
+			for _, v := range versionsResp.Versions {
+				versions = append(versions, v.Version)
+
+				if defaultVersion == "" {
+					defaultVersion = v.Version
 				}
 			}
+		}
+	}
+
+	return &view.Baseline{
+		PackageId: baselinePackage.Id,
+		Name:      baselinePackage.Name,
+		Url:       fmt.Sprintf("%s/portal/packages/%s/%s?mode=overview&item=summary", d.apihubUrl, baselinePackage.Id, url.PathEscape(defaultVersion)),
+		Versions:  versions,
+	}
+}
 
-			srvToAdd := view.Service{
-				Id:             serviceId,
-				Name:           serviceName,
-				Url:            baseUrl,
-				Documents:      documents,
-				Baseline:       baselineObj,
-				Labels:         labelsToAdd,
-				ProxyServerUrl: utils.MakeCustomProxyPath(agentId, namespace, serviceId),
-				Error:          errorStr,
-				DiagnosticInfo: diagnostic,
+// retrieveDocumentsFromPorts searches for documents on all the discovery ports in parallel. Service without ports is probed on the default port of the scheme.
+// Document found on several ports with the same content is returned once, for the port which goes first.
+// Required document from the discovery config without port fails the discovery only if it's missing on all the ports.
+func (d *discoveryServiceImpl) retrieveDocumentsFromPorts(ctx goctx.Context, srv entity.Service, ports []entity.Port, serviceName string, discoveryUrls view.DocumentDiscoveryUrls) (*view.DiscoveryResult, error) {
+	if len(ports) == 0 {
+		baseUrl := buildBaseurl(srv)
+		result, err := d.documentsDiscoveryService.RetrieveDocuments(ctx, baseUrl, serviceName, discoveryUrls)
+		if result != nil {
+			for i := range result.Documents {
+				result.Documents[i].BaseUrl = baseUrl
 			}
-			d.serviceListCache.addService(namespace, workspaceId, srvToAdd)
-			wg.Done()
-		})
+		}
+		return result, err
 	}
 
+	baseUrls := make([]string, len(ports))
+	for i := range ports {
+		baseUrls[i] = buildPortBaseurl(srv, ports[i])
+	}
+	results := make([]*view.DiscoveryResult, len(ports))
+	errs := make(map[int]error)
+	errsMutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i := range ports {
+		i := i
+		wg.Add(1)
+		utils.SafeAsync(func() {
+			defer wg.Done()
+			portCtx := ctx
+			if isSpeculativePort(srv, ports[i]) {
+				// failed requests to the port which may not serve http at all are not worth retrying
+				portCtx = client.WithoutRetries(ctx)
+			}
+			portUrls := getPortDiscoveryUrls(discoveryUrls, ports[i], len(ports) > 1)
+			result, err := d.documentsDiscoveryService.RetrieveDocuments(portCtx, baseUrls[i], serviceName, portUrls)
+			results[i] = result
+			if err != nil {
+				errsMutex.Lock()
+				errs[i] = fmt.Errorf("port %d: %w", ports[i].Port, err)
+				errsMutex.Unlock()
+			}
+		})
+	}
 	wg.Wait()
 
-	log.Infof("Discovery for namespace %s took %dms", namespace, time.Since(start).Milliseconds())
+	merged := &view.DiscoveryResult{Documents: make([]view.Document, 0)}
+	foundDocuments := make(map[string]bool)
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		merged.EndpointCalls = append(merged.EndpointCalls, result.EndpointCalls...)
+		merged.RetriedCalls = append(merged.RetriedCalls, result.RetriedCalls...)
+		for _, document := range result.Documents {
+			key := document.DocPath + "|" + document.Hash
+			if foundDocuments[key] {
+				continue
+			}
+			foundDocuments[key] = true
+			document.Port = ports[i].Port
+			document.BaseUrl = baseUrls[i]
+			merged.Documents = append(merged.Documents, document)
+		}
+	}
+	// file ids are unique within single port only
+	merged.Documents = makeUniqueFileIds(merged.Documents)
+	if err := checkRequiredConfigDocuments(discoveryUrls.Config, ports, merged.Documents); err != nil {
+		errs[len(ports)] = err
+	}
+	return merged, utils.FilterResultErrorsMap(errs)
+}
+
+// getPortDiscoveryUrls leaves the config documents requested on the port. Document without port is not required on each of several ports,
+// since it's usually served by one of them, it's checked by checkRequiredConfigDocuments instead.
+func getPortDiscoveryUrls(urls view.DocumentDiscoveryUrls, port entity.Port, severalPorts bool) view.DocumentDiscoveryUrls {
+	if urls.Config == nil || len(urls.Config.Documents) == 0 {
+		return urls
+	}
+	config := *urls.Config
+	config.Documents = make([]view.DiscoveryConfigDocument, 0, len(urls.Config.Documents))
+	for _, document := range urls.Config.Documents {
+		if !document.IsServedOn(port.Name, port.Port) {
+			continue
+		}
+		if severalPorts && strings.TrimSpace(document.Port) == "" {
+			required := false
+			document.Required = &required
+		}
+		config.Documents = append(config.Documents, document)
+	}
+	urls.Config = &config
+	return urls
+}
+
+// checkRequiredConfigDocuments reports required config documents which are not requested on any port or not found on any of several ports.
+// Failures of the documents bound to the port are reported by the port itself.
+func checkRequiredConfigDocuments(config *view.DiscoveryConfig, ports []entity.Port, documents []view.Document) error {
+	if config == nil {
+		return nil
+	}
+	var errs []string
+	for _, configDocument := range config.Documents {
+		if !configDocument.IsRequired() {
+			continue
+		}
+		if strings.TrimSpace(configDocument.Port) != "" {
+			served := false
+			for _, port := range ports {
+				served = served || configDocument.IsServedOn(port.Name, port.Port)
+			}
+			if !served {
+				errs = append(errs, fmt.Sprintf("Port %s of required document %s is not a discovery port", configDocument.Port, configDocument.Url))
+			}
+			continue
+		}
+		if len(ports) < 2 {
+			continue
+		}
+		found := false
+		for _, document := range documents {
+			found = found || document.DocPath == configDocument.MakeDocumentUrl()
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("Required document %s is not found on any of the discovery ports", configDocument.Url))
+		}
+	}
+	return utils.FilterResultErrors(errs)
+}
 
-	d.serviceListCache.setResultStatus(namespace, workspaceId, view.StatusComplete, "")
+// setResultStatus updates status of the discovery result and finishes the job unless the run is cancelled. Cancelled run must not override the status set on cancellation or by the run that replaced it.
+func (d *discoveryServiceImpl) setResultStatus(ctx goctx.Context, jobId string, namespace string, workspaceId string, status view.StatusEnum, details string) {
+	if ctx.Err() != nil {
+		d.discoveryJobCache.finishJob(jobId, view.StatusCancelled, "discovery was cancelled")
+		return
+	}
+	d.serviceListCache.setResultStatus(namespace, workspaceId, jobId, status, details)
+	d.discoveryJobCache.finishJob(jobId, status, details)
+}
+
+// isServiceReady returns false if the service deployment expects running pods, but none of them is ready
+func isServiceReady(srv entity.Service, servicePods []entity.Pod, deployment *entity.Deployment) bool {
+	if srv.Spec.Type == "ExternalName" { // ExternalName service do not have pods in local namespace, so the check is not applicable.
+		return true
+	}
+	// Some deployments may be scaled down intentionally, need to check replicas count
+	if deployment == nil || deployment.Spec.Replicas == nil || *deployment.Spec.Replicas == 0 {
+		return true
+	}
+	for _, svcPod := range servicePods {
+		for _, containerStatus := range svcPod.Status.ContainerStatuses {
+			if containerStatus.Ready {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func getPodsForSelector(allPods []entity.Pod, selector map[string]string) []entity.Pod {
@@ -333,19 +903,23 @@ func getPodsForSelector(allPods []entity.Pod, selector map[string]string) []enti
 
 func getDeploymentForService(allDeployments []entity.Deployment, selector map[string]string) *entity.Deployment {
 	for _, deployment := range allDeployments {
-		matchSelectors := 0
-		for k, v := range selector {
-			if deployment.Labels[k] == v {
-				matchSelectors++
-			}
-		}
-		if matchSelectors == len(selector) {
+		if labelsMatchSelector(deployment.Labels, selector) {
 			return &deployment
 		}
 	}
 	return nil
 }
 
+func labelsMatchSelector(labels map[string]string, selector map[string]string) bool {
+	matchSelectors := 0
+	for k, v := range selector {
+		if labels[k] == v {
+			matchSelectors++
+		}
+	}
+	return matchSelectors == len(selector)
+}
+
 func getAllLabelsForService(service entity.Service, pods []entity.Pod) map[string]string {
 	result := map[string]string{}
 	for k, v := range service.Labels {
@@ -383,21 +957,129 @@ func getServiceName(nameFromKuber string, annotations map[string]string) string
 	}
 }
 
+// buildBaseurl returns url of the primary discovery port of the service
 func buildBaseurl(srv entity.Service) string {
-	// TODO: https support
-	baseUrl := "http://" + srv.Name + "." + srv.Namespace + ".svc.cluster.local" + ":"
+	ports := getDiscoveryPorts(srv)
+	if len(ports) == 0 {
+		scheme := "http"
+		if isExternalService(srv) && getExternalScheme(srv) != "" {
+			scheme = getExternalScheme(srv)
+		}
+		return scheme + "://" + getServiceHost(srv)
+	}
+	return buildPortBaseurl(srv, ports[0])
+}
+
+func buildPortBaseurl(srv entity.Service, port entity.Port) string {
+	scheme := "http"
+	if isExternalService(srv) && getExternalScheme(srv) != "" {
+		scheme = getExternalScheme(srv)
+	} else if isTlsPort(srv, port) {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(getServiceHost(srv), strconv.Itoa(int(port.Port)))
+}
+
+// isTlsPort checks if the port is listed in the TLS ports annotation. Without the annotation the port is TLS one if it's named https or numbered 443/8443.
+func isTlsPort(srv entity.Service, port entity.Port) bool {
+	if value, exists := srv.Annotations[view.CustomK8sDiscoveryTlsPorts]; exists {
+		for _, portRef := range strings.Split(value, ",") {
+			portRef = strings.TrimSpace(portRef)
+			if portRef != "" && (portRef == port.Name || portRef == strconv.Itoa(int(port.Port))) {
+				return true
+			}
+		}
+		return false
+	}
+	return port.Name == "https" || strings.HasPrefix(port.Name, "https-") || port.Port == 443 || port.Port == 8443
+}
+
+// getDiscoveryPorts returns ports listed in the discovery ports annotation in the listed order.
+// Without the annotation all TCP ports are returned, the ones which look like http go first.
+// Port annotation of the external service takes precedence over the service ports.
+func getDiscoveryPorts(srv entity.Service) []entity.Port {
+	if isExternalService(srv) {
+		if port := getExternalPort(srv); port != nil {
+			return []entity.Port{*port}
+		}
+	}
+	if value := strings.TrimSpace(srv.Annotations[view.CustomK8sDiscoveryPorts]); value != "" {
+		ports := make([]entity.Port, 0)
+		for _, portRef := range strings.Split(value, ",") {
+			portRef = strings.TrimSpace(portRef)
+			if portRef == "" {
+				continue
+			}
+			port := findServicePort(srv, portRef)
+			if port == nil {
+				log.Warnf("Port %s from annotation %s is not found in service %s", portRef, view.CustomK8sDiscoveryPorts, srv.Name)
+				continue
+			}
+			if !containsPort(ports, port.Port) {
+				ports = append(ports, *port)
+			}
+		}
+		if len(ports) > 0 {
+			return ports
+		}
+		log.Warnf("None of ports from annotation %s is found in service %s, all TCP ports are used", view.CustomK8sDiscoveryPorts, srv.Name)
+	}
+
+	httpPorts := make([]entity.Port, 0)
+	otherPorts := make([]entity.Port, 0)
+	for _, port := range srv.Spec.Ports {
+		if port.Protocol != "" && !strings.EqualFold(port.Protocol, "TCP") {
+			continue
+		}
+		if isHttpPort(port) {
+			httpPorts = append(httpPorts, port)
+		} else {
+			otherPorts = append(otherPorts, port)
+		}
+	}
+	return append(httpPorts, otherPorts...)
+}
+
+func isHttpPort(port entity.Port) bool {
+	return port.Name == "web" || port.Name == "http" || port.Port == 8080 || port.Port == 80 || port.Port == 443 || port.Port == 8443
+}
+
+// isSpeculativePort returns true if the port is probed only because all the service ports are, i.e. it's neither listed in the discovery ports annotation nor looks like http one
+func isSpeculativePort(srv entity.Service, port entity.Port) bool {
+	if isExternalService(srv) || isHttpPort(port) || isTlsPort(srv, port) || strings.HasPrefix(port.Name, "http-") {
+		return false
+	}
+	for _, portRef := range strings.Split(srv.Annotations[view.CustomK8sDiscoveryPorts], ",") {
+		portRef = strings.TrimSpace(portRef)
+		if portRef != "" && (portRef == port.Name || portRef == strconv.Itoa(int(port.Port))) {
+			return false
+		}
+	}
+	return true
+}
+
+// findServicePort returns the port with the given name or number. Returns nil if there's no such port.
+func findServicePort(srv entity.Service, portRef string) *entity.Port {
 	for _, port := range srv.Spec.Ports {
-		if port.Name == "web" || port.Name == "http" || port.Port == 8080 || port.Port == 80 || port.Port == 443 || port.Port == 8443 {
-			baseUrl += strconv.Itoa(int(port.Port))
-			break
+		if port.Name == portRef || strconv.Itoa(int(port.Port)) == portRef {
+			return &port
+		}
+	}
+	return nil
+}
+
+func containsPort(ports []entity.Port, number int32) bool {
+	for _, port := range ports {
+		if port.Port == number {
+			return true
 		}
 	}
-	return baseUrl
+	return false
 }
 
 const xApiKindLabel = "apihub/x-api-kind"
 
-func (d discoveryServiceImpl) GetServiceUrl(namespace string, serviceId string) (string, error) {
+func (d *discoveryServiceImpl) GetServiceUrl(namespace string, serviceId string) (string, error) {
 	ctx := goctx.Background()
 	list, err := d.paasClient.GetServiceList(ctx, namespace, filter.Meta{})
 	if err != nil {
@@ -406,6 +1088,7 @@ func (d discoveryServiceImpl) GetServiceUrl(namespace string, serviceId string)
 	}
 	for _, namespaceService := range list {
 		if namespaceService.Name == serviceId {
+			resolveExternalHosts(ctx, []entity.Service{namespaceService})
 			return buildBaseurl(namespaceService), nil
 		}
 	}
@@ -0,0 +1,534 @@
+package service
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/Netcracker/qubership-apihub-agent/exception"
+	"github.com/Netcracker/qubership-apihub-agent/view"
+)
+
+type DiscoveryDiffService interface {
+	// GetDiscoveryDiff compares results of two discovery jobs. Current discovery result is used if toJobId is empty.
+	// Previous complete job is used if fromJobId is empty.
+	GetDiscoveryDiff(namespace string, workspaceId string, fromJobId string, toJobId string) (*view.DiscoveryDiff, error)
+	// CompareNamespaces compares current discovery results of two namespaces
+	CompareNamespaces(workspaceId string, namespace string, targetNamespace string) (*view.NamespaceComparison, error)
+	// CompareBlueGreenVersions compares documents of two blue-green versions of the service. The active version is compared with the other one by default.
+	CompareBlueGreenVersions(namespace string, workspaceId string, serviceName string, serviceId string, targetServiceId string) (*view.BlueGreenComparison, error)
+}
+
+func NewDiscoveryDiffService(discoveryJobCache DiscoveryJobCache, serviceListCache ServiceListCache) DiscoveryDiffService {
+	return &discoveryDiffServiceImpl{
+		discoveryJobCache: discoveryJobCache,
+		serviceListCache:  serviceListCache,
+	}
+}
+
+type discoveryDiffServiceImpl struct {
+	discoveryJobCache DiscoveryJobCache
+	serviceListCache  ServiceListCache
+}
+
+func (d *discoveryDiffServiceImpl) GetDiscoveryDiff(namespace string, workspaceId string, fromJobId string, toJobId string) (*view.DiscoveryDiff, error) {
+	var toServices []view.DiscoveryJobService
+	if toJobId != "" {
+		toJob, err := d.getCompleteJob(namespace, workspaceId, toJobId)
+		if err != nil {
+			return nil, err
+		}
+		toServices = toJob.Services
+	} else {
+		services, err := d.getCompleteDiscoveredServices(namespace, workspaceId)
+		if err != nil {
+			return nil, err
+		}
+		toServices = makeDiscoveryJobServices(services)
+	}
+
+	var fromServices []view.DiscoveryJobService
+	if fromJobId == "" && toJobId == "" {
+		// the cache keeps the result the current one was compared with, so it doesn't depend on the jobs history of this replica
+		previousServices, previousJobId := d.serviceListCache.GetPreviousServices(namespace, workspaceId)
+		if previousServices == nil {
+			return nil, makeNoPreviousDiscoveryError(namespace, workspaceId)
+		}
+		services := make([]view.Service, 0, len(previousServices))
+		for _, srv := range previousServices {
+			services = append(services, srv)
+		}
+		fromServices = makeDiscoveryJobServices(services)
+		fromJobId = previousJobId
+	} else {
+		if fromJobId == "" {
+			fromJobId = d.findPreviousCompleteJobId(namespace, workspaceId, toJobId)
+			if fromJobId == "" {
+				return nil, makeNoPreviousDiscoveryError(namespace, workspaceId)
+			}
+		}
+		fromJob, err := d.getCompleteJob(namespace, workspaceId, fromJobId)
+		if err != nil {
+			return nil, err
+		}
+		fromServices = fromJob.Services
+	}
+
+	diff := compareDiscoveryResults(fromServices, toServices)
+	diff.Namespace = namespace
+	diff.WorkspaceId = workspaceId
+	diff.FromJobId = fromJobId
+	diff.ToJobId = toJobId
+	return diff, nil
+}
+
+func makeNoPreviousDiscoveryError(namespace string, workspaceId string) error {
+	return &exception.CustomError{
+		Status:  http.StatusNotFound,
+		Code:    exception.NoPreviousDiscovery,
+		Message: exception.NoPreviousDiscoveryMsg,
+		Params:  map[string]interface{}{"namespace": namespace, "workspaceId": workspaceId},
+	}
+}
+
+// getCompleteDiscoveredServices returns current discovery result. Failed or cancelled discovery has incomplete result, which can't be compared.
+func (d *discoveryDiffServiceImpl) getCompleteDiscoveredServices(namespace string, workspaceId string) ([]view.Service, error) {
+	services, status, _ := d.serviceListCache.GetServicesList(namespace, workspaceId)
+	if err := makeDiscoveryNotFinishedError(namespace, workspaceId, status); err != nil {
+		return nil, err
+	}
+	if status != view.StatusComplete {
+		return nil, &exception.CustomError{
+			Status:  http.StatusConflict,
+			Code:    exception.DiscoveryNotComplete,
+			Message: exception.DiscoveryNotCompleteMsg,
+			Params:  map[string]interface{}{"namespace": namespace, "workspaceId": workspaceId, "status": status},
+		}
+	}
+	return services, nil
+}
+
+func makeDiscoveryJobServices(services []view.Service) []view.DiscoveryJobService {
+	result := make([]view.DiscoveryJobService, len(services))
+	for i, srv := range services {
+		result[i] = view.DiscoveryJobService{
+			Id:        srv.Id,
+			Name:      srv.Name,
+			NotReady:  srv.NotReady,
+			Documents: view.MakeDiscoveryJobDocuments(srv.Documents),
+		}
+	}
+	return result
+}
+
+// makeDiscoveryNotFinishedError returns nil if the discovery with the status is finished
+func makeDiscoveryNotFinishedError(namespace string, workspaceId string, status view.StatusEnum) error {
+	switch status {
+	case view.StatusNone:
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.NamespaceNotDiscovered,
+			Message: exception.NamespaceNotDiscoveredMsg,
+			Params:  map[string]interface{}{"namespace": namespace, "workspaceId": workspaceId},
+		}
+	case view.StatusRunning:
+		return &exception.CustomError{
+			Status:  http.StatusConflict,
+			Code:    exception.DiscoveryIsRunning,
+			Message: exception.DiscoveryIsRunningMsg,
+			Params:  map[string]interface{}{"namespace": namespace, "workspaceId": workspaceId},
+		}
+	}
+	return nil
+}
+
+// getCompleteJob returns the job with results. Jobs of other namespaces and workspaces are treated as not found.
+func (d *discoveryDiffServiceImpl) getCompleteJob(namespace string, workspaceId string, jobId string) (*view.DiscoveryJob, error) {
+	job := d.discoveryJobCache.GetJob(jobId)
+	if job == nil || job.Namespace != namespace || job.WorkspaceId != workspaceId {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.DiscoveryJobNotFound,
+			Message: exception.DiscoveryJobNotFoundMsg,
+			Params:  map[string]interface{}{"jobId": jobId},
+		}
+	}
+	if job.Status != view.StatusComplete {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.DiscoveryJobNotComplete,
+			Message: exception.DiscoveryJobNotCompleteMsg,
+			Params:  map[string]interface{}{"jobId": jobId},
+		}
+	}
+	return job, nil
+}
+
+// findPreviousCompleteJobId returns the latest complete job started before the given one
+func (d *discoveryDiffServiceImpl) findPreviousCompleteJobId(namespace string, workspaceId string, toJobId string) string {
+	toJobFound := false
+	for _, job := range d.discoveryJobCache.ListJobs(namespace, workspaceId, maxDiscoveryJobs) {
+		if job.Status != view.StatusComplete {
+			continue
+		}
+		if toJobFound {
+			return job.Id
+		}
+		if job.Id == toJobId {
+			toJobFound = true
+		}
+	}
+	return ""
+}
+
+func compareDiscoveryResults(fromServices []view.DiscoveryJobService, toServices []view.DiscoveryJobService) *view.DiscoveryDiff {
+	diff := &view.DiscoveryDiff{
+		AddedServices:   make([]view.DiscoveryDiffService, 0),
+		RemovedServices: make([]view.DiscoveryDiffService, 0),
+		ChangedServices: make([]view.DiscoveryDiffService, 0),
+	}
+	fromServicesMap := make(map[string]view.DiscoveryJobService, len(fromServices))
+	for _, srv := range fromServices {
+		fromServicesMap[srv.Id] = srv
+	}
+	for _, toSrv := range toServices {
+		fromSrv, existed := fromServicesMap[toSrv.Id]
+		if !existed {
+			diff.AddedServices = append(diff.AddedServices, view.DiscoveryDiffService{Id: toSrv.Id, Name: toSrv.Name, AddedDocuments: toSrv.Documents})
+			continue
+		}
+		delete(fromServicesMap, toSrv.Id)
+		if fromSrv.NotReady || toSrv.NotReady {
+			// documents of not ready service are unknown
+			continue
+		}
+		if srvDiff := compareServiceDocuments(fromSrv, toSrv); srvDiff != nil {
+			diff.ChangedServices = append(diff.ChangedServices, *srvDiff)
+		}
+	}
+	for _, fromSrv := range fromServicesMap {
+		diff.RemovedServices = append(diff.RemovedServices, view.DiscoveryDiffService{Id: fromSrv.Id, Name: fromSrv.Name, RemovedDocuments: fromSrv.Documents})
+	}
+	for _, services := range [][]view.DiscoveryDiffService{diff.AddedServices, diff.RemovedServices, diff.ChangedServices} {
+		sort.Slice(services, func(i, j int) bool {
+			return services[i].Name < services[j].Name
+		})
+	}
+	return diff
+}
+
+// compareServiceDocuments matches documents by port and path. Returns nil if documents are not changed.
+func compareServiceDocuments(fromSrv view.DiscoveryJobService, toSrv view.DiscoveryJobService) *view.DiscoveryDiffService {
+	srvDiff := view.DiscoveryDiffService{Id: toSrv.Id, Name: toSrv.Name}
+	fromDocs := make(map[string]view.DiscoveryJobDocument, len(fromSrv.Documents))
+	for _, doc := range fromSrv.Documents {
+		fromDocs[makeDocumentKey(doc.Port, doc.DocPath)] = doc
+	}
+	for _, toDoc := range toSrv.Documents {
+		fromDoc, existed := fromDocs[makeDocumentKey(toDoc.Port, toDoc.DocPath)]
+		if !existed {
+			srvDiff.AddedDocuments = append(srvDiff.AddedDocuments, toDoc)
+			continue
+		}
+		delete(fromDocs, makeDocumentKey(toDoc.Port, toDoc.DocPath))
+		if fromDoc.Hash != toDoc.Hash {
+			srvDiff.ChangedDocuments = append(srvDiff.ChangedDocuments, toDoc)
+		}
+	}
+	for _, doc := range fromSrv.Documents {
+		if _, removed := fromDocs[makeDocumentKey(doc.Port, doc.DocPath)]; removed {
+			srvDiff.RemovedDocuments = append(srvDiff.RemovedDocuments, doc)
+		}
+	}
+	if len(srvDiff.AddedDocuments) == 0 && len(srvDiff.RemovedDocuments) == 0 && len(srvDiff.ChangedDocuments) == 0 {
+		return nil
+	}
+	return &srvDiff
+}
+
+func (d *discoveryDiffServiceImpl) CompareNamespaces(workspaceId string, namespace string, targetNamespace string) (*view.NamespaceComparison, error) {
+	// partial result would report services the discovery didn't reach as missing
+	services, err := d.getCompleteDiscoveredServices(namespace, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	targetServices, err := d.getCompleteDiscoveredServices(targetNamespace, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	comparison := compareNamespaceServices(groupServicesByName(services), groupServicesByName(targetServices))
+	comparison.WorkspaceId = workspaceId
+	comparison.Namespace = namespace
+	comparison.TargetNamespace = targetNamespace
+	return comparison, nil
+}
+
+// serviceGroup contains services with the same name, e.g. blue-green versions of the service
+type serviceGroup struct {
+	ids       []string
+	notReady  bool
+	documents map[string]view.Document
+}
+
+// groupServicesByName merges documents of the services with the same name. Document of the active blue-green version, then of the service with the lowest id
+// is used if several services have the same document port and path. Group is not ready if none of its services is ready.
+func groupServicesByName(services []view.Service) map[string]*serviceGroup {
+	sorted := make([]view.Service, len(services))
+	copy(sorted, services)
+	sort.Slice(sorted, func(i, j int) bool {
+		iActive := sorted[i].BlueGreen != nil && sorted[i].BlueGreen.Active
+		jActive := sorted[j].BlueGreen != nil && sorted[j].BlueGreen.Active
+		if iActive != jActive {
+			return iActive
+		}
+		return sorted[i].Id < sorted[j].Id
+	})
+	groups := make(map[string]*serviceGroup)
+	for _, srv := range sorted {
+		group, exists := groups[srv.Name]
+		if !exists {
+			group = &serviceGroup{notReady: true, documents: make(map[string]view.Document)}
+			groups[srv.Name] = group
+		}
+		group.ids = append(group.ids, srv.Id)
+		if srv.NotReady {
+			continue
+		}
+		group.notReady = false
+		for _, doc := range srv.Documents {
+			key := makeDocumentKey(doc.Port, doc.DocPath)
+			if _, exists := group.documents[key]; !exists {
+				group.documents[key] = doc
+			}
+		}
+	}
+	return groups
+}
+
+func compareNamespaceServices(groups map[string]*serviceGroup, targetGroups map[string]*serviceGroup) *view.NamespaceComparison {
+	comparison := &view.NamespaceComparison{
+		OnlyInNamespace:     make([]view.NamespaceComparisonService, 0),
+		OnlyInTarget:        make([]view.NamespaceComparisonService, 0),
+		ChangedServices:     make([]view.NamespaceComparisonService, 0),
+		NotComparedServices: make([]view.NamespaceComparisonService, 0),
+	}
+	for name, group := range groups {
+		targetGroup, exists := targetGroups[name]
+		if !exists {
+			comparison.OnlyInNamespace = append(comparison.OnlyInNamespace, view.NamespaceComparisonService{
+				Name:                name,
+				ServiceIds:          group.ids,
+				OnlyInNamespaceDocs: makeSortedDocuments(group.documents),
+			})
+			continue
+		}
+		srvComparison := view.NamespaceComparisonService{Name: name, ServiceIds: group.ids, TargetServiceIds: targetGroup.ids}
+		if group.notReady || targetGroup.notReady {
+			comparison.NotComparedServices = append(comparison.NotComparedServices, srvComparison)
+			continue
+		}
+		onlyInNamespace, onlyInTarget, changed := compareDocuments(group.documents, targetGroup.documents)
+		if len(onlyInNamespace) == 0 && len(onlyInTarget) == 0 && len(changed) == 0 {
+			continue
+		}
+		srvComparison.OnlyInNamespaceDocs = makeSortedDocuments(onlyInNamespace)
+		srvComparison.OnlyInTargetDocs = makeSortedDocuments(onlyInTarget)
+		srvComparison.ChangedDocuments = changed
+		comparison.ChangedServices = append(comparison.ChangedServices, srvComparison)
+	}
+	for name, targetGroup := range targetGroups {
+		if _, exists := groups[name]; !exists {
+			comparison.OnlyInTarget = append(comparison.OnlyInTarget, view.NamespaceComparisonService{
+				Name:             name,
+				TargetServiceIds: targetGroup.ids,
+				OnlyInTargetDocs: makeSortedDocuments(targetGroup.documents),
+			})
+		}
+	}
+	for _, services := range [][]view.NamespaceComparisonService{comparison.OnlyInNamespace, comparison.OnlyInTarget, comparison.ChangedServices, comparison.NotComparedServices} {
+		sort.Slice(services, func(i, j int) bool {
+			return services[i].Name < services[j].Name
+		})
+	}
+	return comparison
+}
+
+// compareDocuments matches documents by the key made by makeDocumentKey. Changed documents are sorted by path and port.
+func compareDocuments(documents map[string]view.Document, targetDocuments map[string]view.Document) (map[string]view.Document, map[string]view.Document, []view.ChangedDocument) {
+	onlyInSource := make(map[string]view.Document)
+	changed := make([]view.ChangedDocument, 0)
+	for key, doc := range documents {
+		targetDoc, exists := targetDocuments[key]
+		if !exists {
+			onlyInSource[key] = doc
+		} else if doc.Hash != targetDoc.Hash {
+			changed = append(changed, view.ChangedDocument{
+				DocPath:    doc.DocPath,
+				Port:       doc.Port,
+				Name:       doc.Name,
+				Type:       doc.Type,
+				Hash:       doc.Hash,
+				TargetHash: targetDoc.Hash,
+			})
+		}
+	}
+	onlyInTarget := make(map[string]view.Document)
+	for key, doc := range targetDocuments {
+		if _, exists := documents[key]; !exists {
+			onlyInTarget[key] = doc
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		if changed[i].DocPath != changed[j].DocPath {
+			return changed[i].DocPath < changed[j].DocPath
+		}
+		return changed[i].Port < changed[j].Port
+	})
+	return onlyInSource, onlyInTarget, changed
+}
+
+func makeSortedDocuments(documents map[string]view.Document) []view.DiscoveryJobDocument {
+	if len(documents) == 0 {
+		return nil
+	}
+	docs := make([]view.Document, 0, len(documents))
+	for _, doc := range documents {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].DocPath != docs[j].DocPath {
+			return docs[i].DocPath < docs[j].DocPath
+		}
+		return docs[i].Port < docs[j].Port
+	})
+	return view.MakeDiscoveryJobDocuments(docs)
+}
+
+func (d *discoveryDiffServiceImpl) CompareBlueGreenVersions(namespace string, workspaceId string, serviceName string, serviceId string, targetServiceId string) (*view.BlueGreenComparison, error) {
+	// version the partial result doesn't contain would look like it lost its documents
+	services, err := d.getCompleteDiscoveredServices(namespace, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]view.Service)
+	for _, srv := range services {
+		if srv.Name == serviceName && srv.BlueGreen != nil {
+			versions[srv.Id] = srv
+		}
+	}
+	if len(versions) == 0 {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.BlueGreenVersionsNotFound,
+			Message: exception.BlueGreenVersionsNotFoundMsg,
+			Params:  map[string]interface{}{"service": serviceName, "namespace": namespace},
+		}
+	}
+
+	if serviceId == "" {
+		serviceId = getActiveBlueGreenVersion(versions)
+		if serviceId == "" {
+			return nil, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.RequiredParamMissing,
+				Message: exception.RequiredParamMissingMsg,
+				Params:  map[string]interface{}{"param": "serviceId"},
+			}
+		}
+	}
+	if targetServiceId == "" {
+		targetServiceId = getSingleOtherBlueGreenVersion(versions, serviceId)
+		if targetServiceId == "" {
+			return nil, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.RequiredParamMissing,
+				Message: exception.RequiredParamMissingMsg,
+				Params:  map[string]interface{}{"param": "targetServiceId"},
+			}
+		}
+	}
+	srv, err := getComparableBlueGreenVersion(versions, serviceName, namespace, serviceId)
+	if err != nil {
+		return nil, err
+	}
+	targetSrv, err := getComparableBlueGreenVersion(versions, serviceName, namespace, targetServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	onlyInService, onlyInTarget, changed := compareDocuments(makeDocumentsByKey(srv.Documents), makeDocumentsByKey(targetSrv.Documents))
+	comparison := &view.BlueGreenComparison{
+		WorkspaceId:       workspaceId,
+		Namespace:         namespace,
+		ServiceName:       serviceName,
+		ServiceId:         serviceId,
+		TargetServiceId:   targetServiceId,
+		OnlyInServiceDocs: makeSortedDocuments(onlyInService),
+		OnlyInTargetDocs:  makeSortedDocuments(onlyInTarget),
+		ChangedDocuments:  changed,
+	}
+	if comparison.OnlyInServiceDocs == nil {
+		comparison.OnlyInServiceDocs = make([]view.DiscoveryJobDocument, 0)
+	}
+	if comparison.OnlyInTargetDocs == nil {
+		comparison.OnlyInTargetDocs = make([]view.DiscoveryJobDocument, 0)
+	}
+	return comparison, nil
+}
+
+// getActiveBlueGreenVersion returns empty string if there's no single active version
+func getActiveBlueGreenVersion(versions map[string]view.Service) string {
+	activeId := ""
+	for id, srv := range versions {
+		if srv.BlueGreen.Active {
+			if activeId != "" {
+				return ""
+			}
+			activeId = id
+		}
+	}
+	return activeId
+}
+
+// getSingleOtherBlueGreenVersion returns empty string if there are several other versions to choose from
+func getSingleOtherBlueGreenVersion(versions map[string]view.Service, serviceId string) string {
+	otherId := ""
+	for id := range versions {
+		if id == serviceId {
+			continue
+		}
+		if otherId != "" {
+			return ""
+		}
+		otherId = id
+	}
+	return otherId
+}
+
+func getComparableBlueGreenVersion(versions map[string]view.Service, serviceName string, namespace string, serviceId string) (*view.Service, error) {
+	srv, exists := versions[serviceId]
+	if !exists {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.BlueGreenVersionNotFound,
+			Message: exception.BlueGreenVersionNotFoundMsg,
+			Params:  map[string]interface{}{"serviceId": serviceId, "service": serviceName, "namespace": namespace},
+		}
+	}
+	if srv.NotReady {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.ServiceNotDiscovered,
+			Message: exception.ServiceNotDiscoveredMsg,
+			Params:  map[string]interface{}{"serviceId": serviceId},
+		}
+	}
+	return &srv, nil
+}
+
+func makeDocumentsByKey(documents []view.Document) map[string]view.Document {
+	result := make(map[string]view.Document, len(documents))
+	for _, doc := range documents {
+		result[makeDocumentKey(doc.Port, doc.DocPath)] = doc
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareServiceDocuments(t *testing.T) {
+	docA := view.DiscoveryJobDocument{DocPath: "/a", Hash: "1"}
+	docAChanged := view.DiscoveryJobDocument{DocPath: "/a", Hash: "2"}
+	docB := view.DiscoveryJobDocument{DocPath: "/b", Hash: "3"}
+	docAOtherPort := view.DiscoveryJobDocument{DocPath: "/a", Port: 8081, Hash: "4"}
+
+	tests := []struct {
+		name     string
+		fromDocs []view.DiscoveryJobDocument
+		toDocs   []view.DiscoveryJobDocument
+		expected *view.DiscoveryDiffService
+	}{
+		{
+			name:     "unchanged",
+			fromDocs: []view.DiscoveryJobDocument{docA, docB},
+			toDocs:   []view.DiscoveryJobDocument{docB, docA},
+			expected: nil,
+		},
+		{
+			name:     "added",
+			fromDocs: []view.DiscoveryJobDocument{docA},
+			toDocs:   []view.DiscoveryJobDocument{docA, docB},
+			expected: &view.DiscoveryDiffService{Id: "s", Name: "s", AddedDocuments: []view.DiscoveryJobDocument{docB}},
+		},
+		{
+			name:     "removed",
+			fromDocs: []view.DiscoveryJobDocument{docA, docB},
+			toDocs:   []view.DiscoveryJobDocument{docA},
+			expected: &view.DiscoveryDiffService{Id: "s", Name: "s", RemovedDocuments: []view.DiscoveryJobDocument{docB}},
+		},
+		{
+			name:     "changed",
+			fromDocs: []view.DiscoveryJobDocument{docA, docB},
+			toDocs:   []view.DiscoveryJobDocument{docAChanged, docB},
+			expected: &view.DiscoveryDiffService{Id: "s", Name: "s", ChangedDocuments: []view.DiscoveryJobDocument{docAChanged}},
+		},
+		{
+			name:     "same path on several ports",
+			fromDocs: []view.DiscoveryJobDocument{docA, docAOtherPort},
+			toDocs:   []view.DiscoveryJobDocument{docAOtherPort, docAChanged},
+			expected: &view.DiscoveryDiffService{Id: "s", Name: "s", ChangedDocuments: []view.DiscoveryJobDocument{docAChanged}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fromSrv := view.DiscoveryJobService{Id: "s", Name: "s", Documents: test.fromDocs}
+			toSrv := view.DiscoveryJobService{Id: "s", Name: "s", Documents: test.toDocs}
+			assert.Equal(t, test.expected, compareServiceDocuments(fromSrv, toSrv))
+		})
+	}
+}
+
+func TestCompareDiscoveryResults(t *testing.T) {
+	doc := view.DiscoveryJobDocument{DocPath: "/a", Hash: "1"}
+	changedDoc := view.DiscoveryJobDocument{DocPath: "/a", Hash: "2"}
+
+	tests := []struct {
+		name            string
+		fromServices    []view.DiscoveryJobService
+		toServices      []view.DiscoveryJobService
+		addedServices   []string
+		removedServices []string
+		changedServices []string
+	}{
+		{
+			name:         "same results",
+			fromServices: []view.DiscoveryJobService{{Id: "a", Name: "a", Documents: []view.DiscoveryJobDocument{doc}}},
+			toServices:   []view.DiscoveryJobService{{Id: "a", Name: "a", Documents: []view.DiscoveryJobDocument{doc}}},
+		},
+		{
+			name:            "added and removed services",
+			fromServices:    []view.DiscoveryJobService{{Id: "a", Name: "a"}, {Id: "b", Name: "b"}},
+			toServices:      []view.DiscoveryJobService{{Id: "b", Name: "b"}, {Id: "c", Name: "c"}},
+			addedServices:   []string{"c"},
+			removedServices: []string{"a"},
+		},
+		{
+			name:            "changed document",
+			fromServices:    []view.DiscoveryJobService{{Id: "a", Name: "a", Documents: []view.DiscoveryJobDocument{doc}}},
+			toServices:      []view.DiscoveryJobService{{Id: "a", Name: "a", Documents: []view.DiscoveryJobDocument{changedDoc}}},
+			changedServices: []string{"a"},
+		},
+		{
+			name:         "not ready service is not compared",
+			fromServices: []view.DiscoveryJobService{{Id: "a", Name: "a", Documents: []view.DiscoveryJobDocument{doc}}},
+			toServices:   []view.DiscoveryJobService{{Id: "a", Name: "a", NotReady: true}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := compareDiscoveryResults(test.fromServices, test.toServices)
+			assert.Equal(t, test.addedServices, diffServiceIds(diff.AddedServices))
+			assert.Equal(t, test.removedServices, diffServiceIds(diff.RemovedServices))
+			assert.Equal(t, test.changedServices, diffServiceIds(diff.ChangedServices))
+		})
+	}
+}
+
+func TestGetDiscoveryDiffComparesWithPreviousResult(t *testing.T) {
+	cache := NewServiceListCache(time.Hour)
+	diffService := NewDiscoveryDiffService(NewDiscoveryJobCache(), cache)
+
+	cache.handleDiscoveryStart("ns", "ws", "job1")
+	cache.addService("ns", "ws", "job1", view.Service{Id: "a", Name: "a"})
+	cache.addService("ns", "ws", "job1", view.Service{Id: "b", Name: "b"})
+	cache.setResultStatus("ns", "ws", "job1", view.StatusComplete, "")
+
+	cache.handleDiscoveryStart("ns", "ws", "job2")
+	cache.addService("ns", "ws", "job2", view.Service{Id: "a", Name: "a"})
+	cache.setResultStatus("ns", "ws", "job2", view.StatusCancelled, "")
+	// cancelled result is incomplete, so it's not compared
+	_, err := diffService.GetDiscoveryDiff("ns", "ws", "", "")
+	assert.Error(t, err)
+
+	cache.handleDiscoveryStart("ns", "ws", "job3")
+	cache.addService("ns", "ws", "job3", view.Service{Id: "a", Name: "a"})
+	cache.addService("ns", "ws", "job3", view.Service{Id: "c", Name: "c"})
+	cache.setResultStatus("ns", "ws", "job3", view.StatusComplete, "")
+
+	diff, err := diffService.GetDiscoveryDiff("ns", "ws", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "job1", diff.FromJobId)
+	assert.Equal(t, []string{"c"}, diffServiceIds(diff.AddedServices))
+	assert.Equal(t, []string{"b"}, diffServiceIds(diff.RemovedServices))
+}
+
+func diffServiceIds(services []view.DiscoveryDiffService) []string {
+	var ids []string
+	for _, srv := range services {
+		ids = append(ids, srv.Id)
+	}
+	return ids
+}
+
+func TestCompareNamespacesRequiresCompleteDiscovery(t *testing.T) {
+	cache := NewServiceListCache(time.Hour)
+	diffService := NewDiscoveryDiffService(NewDiscoveryJobCache(), cache)
+	for _, namespace := range []string{"ns1", "ns2"} {
+		cache.handleDiscoveryStart(namespace, "ws", "job-"+namespace)
+		cache.addService(namespace, "ws", "job-"+namespace, view.Service{Id: "a", Name: "a"})
+	}
+	cache.setResultStatus("ns1", "ws", "job-ns1", view.StatusComplete, "")
+	cache.setResultStatus("ns2", "ws", "job-ns2", view.StatusError, "discovery failed")
+
+	_, err := diffService.CompareNamespaces("ws", "ns1", "ns2")
+	assert.Error(t, err)
+	_, err = diffService.CompareNamespaces("ws", "ns2", "ns1")
+	assert.Error(t, err)
+}
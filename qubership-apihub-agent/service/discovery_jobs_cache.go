@@ -0,0 +1,131 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/google/uuid"
+)
+
+// only the latest jobs are kept in memory
+const maxDiscoveryJobs = 1000
+
+type DiscoveryJobCache interface {
+	ListJobs(namespace string, workspaceId string, limit int) []view.DiscoveryJob
+	GetJob(jobId string) *view.DiscoveryJob
+	createJob(namespace string, workspaceId string, userId string) string
+	addServiceResult(jobId string, result view.DiscoveryJobService)
+	finishJob(jobId string, status view.StatusEnum, details string)
+}
+
+func NewDiscoveryJobCache() DiscoveryJobCache {
+	return &discoveryJobCacheImpl{
+		jobs:    map[string]*view.DiscoveryJob{},
+		jobsIds: make([]string, 0),
+	}
+}
+
+type discoveryJobCacheImpl struct {
+	jobs     map[string]*view.DiscoveryJob
+	jobsIds  []string // ordered by creation time
+	jobMutex sync.RWMutex
+}
+
+// ListJobs returns the latest jobs first. Per service results are not included.
+func (d *discoveryJobCacheImpl) ListJobs(namespace string, workspaceId string, limit int) []view.DiscoveryJob {
+	d.jobMutex.RLock()
+	defer d.jobMutex.RUnlock()
+
+	result := make([]view.DiscoveryJob, 0)
+	for i := len(d.jobsIds) - 1; i >= 0 && len(result) < limit; i-- {
+		job := d.jobs[d.jobsIds[i]]
+		if namespace != "" && job.Namespace != namespace {
+			continue
+		}
+		if workspaceId != "" && job.WorkspaceId != workspaceId {
+			continue
+		}
+		jobCopy := copyJob(job)
+		jobCopy.Services = nil
+		result = append(result, jobCopy)
+	}
+	return result
+}
+
+func (d *discoveryJobCacheImpl) GetJob(jobId string) *view.DiscoveryJob {
+	d.jobMutex.RLock()
+	defer d.jobMutex.RUnlock()
+
+	job, exists := d.jobs[jobId]
+	if !exists {
+		return nil
+	}
+	jobCopy := copyJob(job)
+	return &jobCopy
+}
+
+func (d *discoveryJobCacheImpl) createJob(namespace string, workspaceId string, userId string) string {
+	d.jobMutex.Lock()
+	defer d.jobMutex.Unlock()
+
+	job := &view.DiscoveryJob{
+		Id:          uuid.NewString(),
+		Namespace:   namespace,
+		WorkspaceId: workspaceId,
+		CreatedBy:   userId,
+		Status:      view.StatusRunning,
+		StartedAt:   time.Now(),
+		Services:    make([]view.DiscoveryJobService, 0),
+	}
+	d.jobs[job.Id] = job
+	d.jobsIds = append(d.jobsIds, job.Id)
+	if len(d.jobsIds) > maxDiscoveryJobs {
+		delete(d.jobs, d.jobsIds[0])
+		d.jobsIds = d.jobsIds[1:]
+	}
+	return job.Id
+}
+
+func (d *discoveryJobCacheImpl) addServiceResult(jobId string, result view.DiscoveryJobService) {
+	d.jobMutex.Lock()
+	defer d.jobMutex.Unlock()
+
+	job, exists := d.jobs[jobId]
+	if !exists || job.Status != view.StatusRunning {
+		return
+	}
+	job.Services = append(job.Services, result)
+	job.ServicesCount++
+	if result.Error != "" {
+		job.FailedServicesCount++
+	}
+}
+
+// finishJob sets terminal status of the job. Already finished job is not changed.
+func (d *discoveryJobCacheImpl) finishJob(jobId string, status view.StatusEnum, details string) {
+	d.jobMutex.Lock()
+	defer d.jobMutex.Unlock()
+
+	job, exists := d.jobs[jobId]
+	if !exists || job.Status != view.StatusRunning {
+		return
+	}
+	finishedAt := time.Now()
+	job.Status = status
+	job.Error = details
+	job.FinishedAt = &finishedAt
+	job.DurationMs = finishedAt.Sub(job.StartedAt).Milliseconds()
+}
+
+func copyJob(job *view.DiscoveryJob) view.DiscoveryJob {
+	jobCopy := *job
+	if job.Services != nil {
+		jobCopy.Services = make([]view.DiscoveryJobService, len(job.Services))
+		copy(jobCopy.Services, job.Services)
+	}
+	if jobCopy.FinishedAt == nil {
+		jobCopy.DurationMs = time.Since(job.StartedAt).Milliseconds()
+	}
+	return jobCopy
+}
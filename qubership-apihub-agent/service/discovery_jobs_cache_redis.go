@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// fields of the job hash. Each service result is kept in its own field numbered by the services counter.
+const redisJobField = "job"
+const redisJobStatusField = "status"
+const redisJobErrorField = "error"
+const redisJobFinishedAtField = "finishedAt"
+const redisJobServicesCountField = "servicesCount"
+const redisJobFailedServicesCountField = "failedServicesCount"
+const redisJobServiceFieldPrefix = "service:"
+
+// addJobServiceScript adds the service result to the running job
+var addJobServiceScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' then
+	return 0
+end
+local count = redis.call('HINCRBY', KEYS[1], 'servicesCount', 1)
+redis.call('HSET', KEYS[1], 'service:' .. count, ARGV[1])
+if ARGV[2] == '1' then
+	redis.call('HINCRBY', KEYS[1], 'failedServicesCount', 1)
+end
+return 1
+`)
+
+// finishJobScript sets terminal status of the running job
+var finishJobScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'status', ARGV[1], 'error', ARGV[2], 'finishedAt', ARGV[3])
+return 1
+`)
+
+// jobHeartbeatScript refreshes the heartbeat of the running job
+var jobHeartbeatScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'heartbeat', ARGV[1])
+return 1
+`)
+
+// NewRedisDiscoveryJobCache creates the cache which keeps discovery jobs in Redis next to the discovery results, so all Agent replicas
+// return the same jobs history. Keys are prefixed by keyPrefix, replicaId must be unique for each replica.
+func NewRedisDiscoveryJobCache(redisUrl string, keyPrefix string, replicaId string) (DiscoveryJobCache, error) {
+	client, err := connectRedis(redisUrl)
+	if err != nil {
+		return nil, err
+	}
+	cache := newRedisDiscoveryJobCache(client, keyPrefix, replicaId)
+	cache.start()
+	return cache, nil
+}
+
+func newRedisDiscoveryJobCache(client redis.UniversalClient, keyPrefix string, replicaId string) *redisDiscoveryJobCacheImpl {
+	return &redisDiscoveryJobCacheImpl{
+		client:      client,
+		keyPrefix:   keyPrefix,
+		replicaId:   replicaId,
+		runningJobs: map[string]struct{}{},
+		stop:        func() {},
+	}
+}
+
+// redisDiscoveryJobCacheImpl keeps each job in its own hash, ids of the latest jobs are kept in the sorted set by the start time.
+// Replica running the job refreshes its heartbeat, running job without heartbeat is reported as interrupted.
+type redisDiscoveryJobCacheImpl struct {
+	client    redis.UniversalClient
+	keyPrefix string
+	replicaId string
+
+	runningJobs map[string]struct{} // jobs created by this replica, guarded by mutex
+	mutex       sync.Mutex
+	stop        context.CancelFunc
+}
+
+func (r *redisDiscoveryJobCacheImpl) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stop = cancel
+	go r.sendHeartbeats(ctx)
+}
+
+// ListJobs returns the latest jobs first. Per service results are not included.
+func (r *redisDiscoveryJobCacheImpl) ListJobs(namespace string, workspaceId string, limit int) []view.DiscoveryJob {
+	ctx := context.Background()
+	jobIds, err := r.client.ZRevRange(ctx, r.jobsKey(), 0, maxDiscoveryJobs-1).Result()
+	if err != nil {
+		log.Errorf("Failed to list discovery jobs in redis: %s", err)
+		return make([]view.DiscoveryJob, 0)
+	}
+	cmds := make([]*redis.SliceCmd, len(jobIds))
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, jobId := range jobIds {
+			cmds[i] = pipe.HMGet(ctx, r.jobKey(jobId), redisJobField, redisJobStatusField, redisJobErrorField, redisJobFinishedAtField,
+				redisJobServicesCountField, redisJobFailedServicesCountField, redisOwnerField, redisHeartbeatField)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to read discovery jobs from redis: %s", err)
+		return make([]view.DiscoveryJob, 0)
+	}
+
+	result := make([]view.DiscoveryJob, 0)
+	for _, cmd := range cmds {
+		if len(result) >= limit {
+			break
+		}
+		job, err := parseRedisJobFields(cmd.Val())
+		if err != nil {
+			log.Errorf("Failed to read discovery job from redis: %s", err)
+			continue
+		}
+		if job == nil {
+			continue
+		}
+		if namespace != "" && job.Namespace != namespace {
+			continue
+		}
+		if workspaceId != "" && job.WorkspaceId != workspaceId {
+			continue
+		}
+		result = append(result, *job)
+	}
+	return result
+}
+
+func (r *redisDiscoveryJobCacheImpl) GetJob(jobId string) *view.DiscoveryJob {
+	fields, err := r.client.HGetAll(context.Background(), r.jobKey(jobId)).Result()
+	if err != nil {
+		log.Errorf("Failed to read discovery job %s from redis: %s", jobId, err)
+		return nil
+	}
+	if fields[redisJobField] == "" {
+		return nil
+	}
+	values := []interface{}{fields[redisJobField], fields[redisJobStatusField], fields[redisJobErrorField], fields[redisJobFinishedAtField],
+		fields[redisJobServicesCountField], fields[redisJobFailedServicesCountField], fields[redisOwnerField], fields[redisHeartbeatField]}
+	job, err := parseRedisJobFields(values)
+	if err != nil {
+		log.Errorf("Failed to read discovery job %s from redis: %s", jobId, err)
+		return nil
+	}
+	type numberedService struct {
+		number  int
+		service view.DiscoveryJobService
+	}
+	services := make([]numberedService, 0)
+	for field, value := range fields {
+		numberStr, isService := strings.CutPrefix(field, redisJobServiceFieldPrefix)
+		if !isService {
+			continue
+		}
+		number, _ := strconv.Atoi(numberStr)
+		var service view.DiscoveryJobService
+		if err := json.Unmarshal([]byte(value), &service); err != nil {
+			log.Errorf("Failed to read result of service of discovery job %s from redis: %s", jobId, err)
+			continue
+		}
+		services = append(services, numberedService{number: number, service: service})
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].number < services[j].number
+	})
+	job.Services = make([]view.DiscoveryJobService, len(services))
+	for i, srv := range services {
+		job.Services[i] = srv.service
+	}
+	return job
+}
+
+func (r *redisDiscoveryJobCacheImpl) createJob(namespace string, workspaceId string, userId string) string {
+	ctx := context.Background()
+	job := view.DiscoveryJob{
+		Id:          uuid.NewString(),
+		Namespace:   namespace,
+		WorkspaceId: workspaceId,
+		CreatedBy:   userId,
+		Status:      view.StatusRunning,
+		StartedAt:   time.Now(),
+	}
+	header, err := json.Marshal(job)
+	if err != nil {
+		log.Errorf("Failed to save discovery job %s to redis: %s", job.Id, err)
+		return job.Id
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, r.jobKey(job.Id), redisJobField, header, redisJobStatusField, string(view.StatusRunning),
+			redisJobServicesCountField, 0, redisJobFailedServicesCountField, 0,
+			redisOwnerField, r.replicaId, redisHeartbeatField, time.Now().UnixMilli())
+		pipe.ZAdd(ctx, r.jobsKey(), redis.Z{Score: float64(job.StartedAt.UnixMilli()), Member: job.Id})
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to save discovery job %s to redis: %s", job.Id, err)
+		return job.Id
+	}
+	r.mutex.Lock()
+	r.runningJobs[job.Id] = struct{}{}
+	r.mutex.Unlock()
+	r.removeOldJobs(ctx)
+	return job.Id
+}
+
+// removeOldJobs keeps only the latest maxDiscoveryJobs jobs
+func (r *redisDiscoveryJobCacheImpl) removeOldJobs(ctx context.Context) {
+	oldJobIds, err := r.client.ZRange(ctx, r.jobsKey(), 0, -maxDiscoveryJobs-1).Result()
+	if err != nil || len(oldJobIds) == 0 {
+		return
+	}
+	keys := make([]string, len(oldJobIds))
+	members := make([]interface{}, len(oldJobIds))
+	for i, jobId := range oldJobIds {
+		keys[i] = r.jobKey(jobId)
+		members[i] = jobId
+	}
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		log.Errorf("Failed to remove old discovery jobs from redis: %s", err)
+		return
+	}
+	r.client.ZRem(ctx, r.jobsKey(), members...)
+}
+
+func (r *redisDiscoveryJobCacheImpl) addServiceResult(jobId string, result view.DiscoveryJobService) {
+	value, err := json.Marshal(result)
+	if err != nil {
+		log.Errorf("Failed to save service %s result of discovery job %s to redis: %s", result.Id, jobId, err)
+		return
+	}
+	failed := "0"
+	if result.Error != "" {
+		failed = "1"
+	}
+	if err := addJobServiceScript.Run(context.Background(), r.client, []string{r.jobKey(jobId)}, value, failed).Err(); err != nil {
+		log.Errorf("Failed to save service %s result of discovery job %s to redis: %s", result.Id, jobId, err)
+	}
+}
+
+// finishJob sets terminal status of the job. Already finished job is not changed.
+func (r *redisDiscoveryJobCacheImpl) finishJob(jobId string, status view.StatusEnum, details string) {
+	r.mutex.Lock()
+	delete(r.runningJobs, jobId)
+	r.mutex.Unlock()
+	err := finishJobScript.Run(context.Background(), r.client, []string{r.jobKey(jobId)}, string(status), details, time.Now().UnixMilli()).Err()
+	if err != nil {
+		log.Errorf("Failed to finish discovery job %s in redis: %s", jobId, err)
+	}
+}
+
+func (r *redisDiscoveryJobCacheImpl) sendHeartbeats(ctx context.Context) {
+	ticker := time.NewTicker(redisHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.mutex.Lock()
+		jobIds := make([]string, 0, len(r.runningJobs))
+		for jobId := range r.runningJobs {
+			jobIds = append(jobIds, jobId)
+		}
+		r.mutex.Unlock()
+		for _, jobId := range jobIds {
+			if err := jobHeartbeatScript.Run(ctx, r.client, []string{r.jobKey(jobId)}, time.Now().UnixMilli()).Err(); err != nil {
+				log.Errorf("Failed to refresh heartbeat of discovery job %s in redis: %s", jobId, err)
+			}
+		}
+	}
+}
+
+// parseRedisJobFields makes the job from the values of header, status, error, finishedAt, servicesCount, failedServicesCount, owner and heartbeat fields.
+// Returns nil if the job doesn't exist.
+func parseRedisJobFields(values []interface{}) (*view.DiscoveryJob, error) {
+	strValues := make([]string, len(values))
+	for i, value := range values {
+		strValues[i], _ = value.(string)
+	}
+	if strValues[0] == "" {
+		return nil, nil
+	}
+	var job view.DiscoveryJob
+	if err := json.Unmarshal([]byte(strValues[0]), &job); err != nil {
+		return nil, err
+	}
+	job.Status = view.StatusEnum(strValues[1])
+	job.Error = strValues[2]
+	job.ServicesCount, _ = strconv.Atoi(strValues[4])
+	job.FailedServicesCount, _ = strconv.Atoi(strValues[5])
+	if finishedAtMs, err := strconv.ParseInt(strValues[3], 10, 64); err == nil {
+		finishedAt := time.UnixMilli(finishedAtMs)
+		job.FinishedAt = &finishedAt
+		job.DurationMs = finishedAt.Sub(job.StartedAt).Milliseconds()
+	} else {
+		job.DurationMs = time.Since(job.StartedAt).Milliseconds()
+	}
+	if job.Status == view.StatusRunning {
+		heartbeatMs, err := strconv.ParseInt(strValues[7], 10, 64)
+		if err != nil {
+			return nil, errors.New("heartbeat of running job is not set")
+		}
+		if time.Since(time.UnixMilli(heartbeatMs)) >= redisHeartbeatTimeout {
+			job.Status = view.StatusError
+			job.Error = fmt.Sprintf("Discovery was interrupted: Agent replica %s stopped", strValues[6])
+		}
+	}
+	return &job, nil
+}
+
+func (r *redisDiscoveryJobCacheImpl) jobKey(jobId string) string {
+	return r.keyPrefix + "job:" + jobId
+}
+
+func (r *redisDiscoveryJobCacheImpl) jobsKey() string {
+	return r.keyPrefix + "jobs"
+}
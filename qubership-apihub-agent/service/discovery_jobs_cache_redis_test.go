@@ -0,0 +1,71 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedisDiscoveryJobCaches(t *testing.T) (*miniredis.Miniredis, DiscoveryJobCache, DiscoveryJobCache) {
+	mr := miniredis.RunT(t)
+	newReplica := func(replicaId string) DiscoveryJobCache {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		cache := newRedisDiscoveryJobCache(client, "test:", replicaId)
+		cache.start()
+		t.Cleanup(cache.stop)
+		return cache
+	}
+	return mr, newReplica("replicaA"), newReplica("replicaB")
+}
+
+func TestRedisDiscoveryJobCacheIsSharedBetweenReplicas(t *testing.T) {
+	_, replicaA, replicaB := newTestRedisDiscoveryJobCaches(t)
+
+	jobId := replicaA.createJob("ns", "ws", "user")
+	replicaA.addServiceResult(jobId, view.DiscoveryJobService{Id: "a", Name: "a"})
+	replicaA.addServiceResult(jobId, view.DiscoveryJobService{Id: "b", Name: "b", Error: "failed"})
+
+	job := replicaB.GetJob(jobId)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, view.StatusRunning, job.Status)
+		assert.Equal(t, 2, job.ServicesCount)
+		assert.Equal(t, 1, job.FailedServicesCount)
+		assert.Equal(t, []string{"a", "b"}, []string{job.Services[0].Id, job.Services[1].Id})
+	}
+
+	replicaA.finishJob(jobId, view.StatusComplete, "")
+	// finished job is not changed anymore
+	replicaA.addServiceResult(jobId, view.DiscoveryJobService{Id: "c", Name: "c"})
+	replicaB.finishJob(jobId, view.StatusError, "failed")
+
+	otherJobId := replicaB.createJob("other", "ws", "user")
+	jobs := replicaB.ListJobs("ns", "", 10)
+	if assert.Len(t, jobs, 1) {
+		assert.Equal(t, jobId, jobs[0].Id)
+		assert.Equal(t, view.StatusComplete, jobs[0].Status)
+		assert.Equal(t, 2, jobs[0].ServicesCount)
+		assert.NotNil(t, jobs[0].FinishedAt)
+		assert.Nil(t, jobs[0].Services)
+	}
+	assert.Equal(t, otherJobId, replicaA.ListJobs("", "", 10)[0].Id)
+	assert.Nil(t, replicaA.GetJob("unknown"))
+}
+
+func TestRedisDiscoveryJobCacheReportsJobOfStoppedReplica(t *testing.T) {
+	mr, replicaA, replicaB := newTestRedisDiscoveryJobCaches(t)
+
+	jobId := replicaA.createJob("ns", "ws", "user")
+	mr.HSet("test:job:"+jobId, redisHeartbeatField, strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10))
+
+	job := replicaB.GetJob(jobId)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, view.StatusError, job.Status)
+		assert.Contains(t, job.Error, "replicaA")
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/utils"
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDiscoveryPorts(t *testing.T) {
+	srv := entity.Service{
+		Metadata: entity.Metadata{Name: "svc", Namespace: "ns"},
+		Spec: entity.ServiceSpec{Ports: []entity.Port{
+			{Name: "management", Port: 9090, Protocol: "TCP"},
+			{Name: "dns", Port: 53, Protocol: "UDP"},
+			{Name: "web", Port: 8080},
+		}},
+	}
+	assert.Equal(t, []int32{8080, 9090}, portNumbers(getDiscoveryPorts(srv)))
+	assert.Equal(t, "http://svc.ns.svc.cluster.local:8080", buildBaseurl(srv))
+
+	srv.Annotations = map[string]string{view.CustomK8sDiscoveryPorts: "management, 8080, unknown"}
+	assert.Equal(t, []int32{9090, 8080}, portNumbers(getDiscoveryPorts(srv)))
+	assert.Equal(t, "http://svc.ns.svc.cluster.local:9090", buildBaseurl(srv))
+
+	srv.Spec.Ports = nil
+	assert.Equal(t, "http://svc.ns.svc.cluster.local", buildBaseurl(srv))
+}
+
+func TestBuildPortBaseurlUsesTls(t *testing.T) {
+	srv := entity.Service{Metadata: entity.Metadata{Name: "svc", Namespace: "ns"}}
+	assert.Equal(t, "https://svc.ns.svc.cluster.local:8443", buildPortBaseurl(srv, entity.Port{Port: 8443}))
+	assert.Equal(t, "https://svc.ns.svc.cluster.local:9000", buildPortBaseurl(srv, entity.Port{Name: "https-api", Port: 9000}))
+	assert.Equal(t, "http://svc.ns.svc.cluster.local:8080", buildPortBaseurl(srv, entity.Port{Name: "web", Port: 8080}))
+
+	srv.Annotations = map[string]string{view.CustomK8sDiscoveryTlsPorts: "web"}
+	assert.Equal(t, "https://svc.ns.svc.cluster.local:8080", buildPortBaseurl(srv, entity.Port{Name: "web", Port: 8080}))
+	assert.Equal(t, "http://svc.ns.svc.cluster.local:8443", buildPortBaseurl(srv, entity.Port{Port: 8443}))
+}
+
+func portNumbers(ports []entity.Port) []int32 {
+	result := make([]int32, 0, len(ports))
+	for _, port := range ports {
+		result = append(result, port.Port)
+	}
+	return result
+}
+
+func TestBuildBaseurlForExternalService(t *testing.T) {
+	srv := entity.Service{
+		Metadata: entity.Metadata{Name: "db-api", Namespace: "ns", Annotations: map[string]string{view.CustomK8sExternalHost: "api.example.com"}},
+		Spec:     entity.ServiceSpec{Type: "ExternalName"},
+	}
+	assert.Equal(t, "http://api.example.com", buildBaseurl(srv))
+
+	srv.Annotations[view.CustomK8sExternalScheme] = "https"
+	assert.Equal(t, "https://api.example.com", buildBaseurl(srv))
+
+	srv.Spec.Ports = []entity.Port{{Name: "web", Port: 8080}}
+	assert.Equal(t, "https://api.example.com:8080", buildBaseurl(srv))
+
+	srv.Annotations[view.CustomK8sExternalPort] = "9443"
+	assert.Equal(t, "https://api.example.com:9443", buildBaseurl(srv))
+}
+
+func TestExternalHostIsResolvedOnce(t *testing.T) {
+	lookups := 0
+	lookupCNAME = func(ctx context.Context, host string) (string, error) {
+		lookups++
+		return "api.example.com.", nil
+	}
+	t.Cleanup(func() {
+		lookupCNAME = net.DefaultResolver.LookupCNAME
+		resolvedExternalHosts.Purge()
+	})
+
+	srv := entity.Service{
+		Metadata: entity.Metadata{Name: "db-api", Namespace: "ns"},
+		Spec:     entity.ServiceSpec{Type: "ExternalName", Ports: []entity.Port{{Name: "web", Port: 8080}}},
+	}
+	assert.Equal(t, "http://db-api.ns.svc.cluster.local:8080", buildBaseurl(srv))
+	assert.Equal(t, 0, lookups)
+
+	resolveExternalHosts(context.Background(), []entity.Service{srv})
+	resolveExternalHosts(context.Background(), []entity.Service{srv})
+	assert.Equal(t, "http://api.example.com:8080", buildBaseurl(srv))
+	assert.Equal(t, "http://api.example.com:8080", buildPortBaseurl(srv, srv.Spec.Ports[0]))
+	assert.Equal(t, 1, lookups)
+}
+
+func TestRetrieveDocumentsFromPortsRequiresConfigDocumentOnAnyPort(t *testing.T) {
+	srv := entity.Service{
+		Metadata: entity.Metadata{Name: "svc", Namespace: "ns"},
+		Spec:     entity.ServiceSpec{Ports: []entity.Port{{Name: "web", Port: 8080}, {Name: "management", Port: 9090}}},
+	}
+	notRequired := false
+	urls := view.DocumentDiscoveryUrls{Config: &view.DiscoveryConfig{Documents: []view.DiscoveryConfigDocument{
+		{Url: "/specs/orders.json", Type: "rest"},
+		{Url: "/specs/metrics.json", Type: "rest", Port: "management"},
+		{Url: "/specs/optional.json", Type: "rest", Required: &notRequired},
+	}}}
+	documentsService := &fakeDocumentsDiscoveryService{served: map[string][]string{
+		"http://svc.ns.svc.cluster.local:8080": {"/specs/orders.json", "/v3/api-docs"},
+		"http://svc.ns.svc.cluster.local:9090": {"/specs/metrics.json", "/v3/api-docs"},
+	}}
+	d := &discoveryServiceImpl{documentsDiscoveryService: documentsService}
+
+	result, err := d.retrieveDocumentsFromPorts(context.Background(), srv, getDiscoveryPorts(srv), "svc", urls)
+	assert.NoError(t, err)
+	assert.Len(t, result.Documents, 4)
+	assert.NotContains(t, documentsService.requested["http://svc.ns.svc.cluster.local:8080"], "/specs/metrics.json")
+
+	urls.Config.Documents = append(urls.Config.Documents, view.DiscoveryConfigDocument{Url: "/specs/missing.json", Type: "rest"})
+	result, err = d.retrieveDocumentsFromPorts(context.Background(), srv, getDiscoveryPorts(srv), "svc", urls)
+	assert.ErrorContains(t, err, "/specs/missing.json")
+	assert.Len(t, result.Documents, 4)
+}
+
+// fakeDocumentsDiscoveryService serves the documents by base url. Failed required config documents are reported the same way as by the runners.
+type fakeDocumentsDiscoveryService struct {
+	served    map[string][]string
+	mutex     sync.Mutex
+	requested map[string][]string
+}
+
+func (f *fakeDocumentsDiscoveryService) RetrieveDocuments(_ context.Context, baseUrl string, _ string, urls view.DocumentDiscoveryUrls) (*view.DiscoveryResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.requested == nil {
+		f.requested = map[string][]string{}
+	}
+	result := &view.DiscoveryResult{}
+	var errs []string
+	for _, ref := range urls.Config.MakeDocumentRefs(time.Second) {
+		f.requested[baseUrl] = append(f.requested[baseUrl], ref.Url)
+		if slices.Contains(f.served[baseUrl], ref.Url) {
+			result.Documents = append(result.Documents, view.Document{DocPath: ref.Url, Hash: baseUrl + ref.Url})
+		} else if ref.Required {
+			errs = append(errs, "Failed to get required document from url "+ref.Url)
+		}
+	}
+	// discovered document is returned regardless of the config documents
+	result.Documents = append(result.Documents, view.Document{DocPath: "/v3/api-docs", Hash: baseUrl})
+	return result, utils.FilterResultErrors(errs)
+}
+
+func (f *fakeDocumentsDiscoveryService) GetRunnerNames(_ view.DiscoveryUrlKind) []string {
+	return nil
+}
+
+func TestRetrieveDocumentsFromPortsKeepsBaseUrlOfPort(t *testing.T) {
+	srv := entity.Service{
+		Metadata: entity.Metadata{Name: "svc", Namespace: "ns"},
+		Spec:     entity.ServiceSpec{Ports: []entity.Port{{Name: "web", Port: 8080}, {Name: "https", Port: 8443}}},
+	}
+	d := &discoveryServiceImpl{documentsDiscoveryService: &fakeDocumentsDiscoveryService{}}
+
+	result, err := d.retrieveDocumentsFromPorts(context.Background(), srv, getDiscoveryPorts(srv), "svc", view.DocumentDiscoveryUrls{})
+	assert.NoError(t, err)
+	baseUrls := map[int32]string{}
+	for _, document := range result.Documents {
+		baseUrls[document.Port] = document.BaseUrl
+	}
+	assert.Equal(t, map[int32]string{8080: "http://svc.ns.svc.cluster.local:8080", 8443: "https://svc.ns.svc.cluster.local:8443"}, baseUrls)
+}
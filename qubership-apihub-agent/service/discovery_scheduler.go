@@ -0,0 +1,139 @@
+package service
+
+import (
+	goctx "context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/secctx"
+	"github.com/Netcracker/qubership-apihub-agent/utils"
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+type DiscoveryScheduler interface {
+	RunDiscoveryScheduler() error
+}
+
+// status of scheduled namespace discovery is checked periodically to release the namespace limiter slot when it's finished
+const scheduledDiscoveryPollInterval = time.Second * 5
+
+// NewDiscoveryScheduler creates scheduler which discovers namespaces in parallel, bounded by namespaceLimiter shared with all namespaces discovery
+func NewDiscoveryScheduler(schedules []view.DiscoverySchedule, discoveryService DiscoveryService, serviceListCache ServiceListCache, namespaceListCache NamespaceListCache,
+	leaderElector LeaderElector, namespaceLimiter utils.ConcurrencyLimiter) DiscoveryScheduler {
+	return &discoverySchedulerImpl{
+		schedules:          schedules,
+		discoveryService:   discoveryService,
+		serviceListCache:   serviceListCache,
+		namespaceListCache: namespaceListCache,
+		leaderElector:      leaderElector,
+		namespaceLimiter:   namespaceLimiter,
+	}
+}
+
+type discoverySchedulerImpl struct {
+	schedules          []view.DiscoverySchedule
+	discoveryService   DiscoveryService
+	serviceListCache   ServiceListCache
+	namespaceListCache NamespaceListCache
+	leaderElector      LeaderElector
+	namespaceLimiter   utils.ConcurrencyLimiter
+}
+
+func (d *discoverySchedulerImpl) RunDiscoveryScheduler() error {
+	if len(d.schedules) == 0 {
+		log.Info("No discovery schedules configured")
+		return nil
+	}
+	c := cron.New()
+	for _, s := range d.schedules {
+		schedule := s
+		if schedule.WorkspaceId == "" {
+			schedule.WorkspaceId = view.DefaultWorkspaceId
+		}
+		running := &atomic.Bool{}
+		_, err := c.AddFunc(schedule.Cron, func() {
+			if !running.CompareAndSwap(false, true) {
+				log.Infof("Skipping scheduled discovery '%s' since its previous run is not finished", schedule.Cron)
+				return
+			}
+			utils.SafeAsync(func() {
+				defer running.Store(false)
+				d.runScheduledDiscovery(schedule)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("invalid cron expression '%s': %w", schedule.Cron, err)
+		}
+		log.Infof("Scheduled discovery '%s' for namespaces %v and workspaceId %s", schedule.Cron, schedule.Namespaces, schedule.WorkspaceId)
+	}
+	c.Start()
+	return nil
+}
+
+func (d *discoverySchedulerImpl) runScheduledDiscovery(schedule view.DiscoverySchedule) {
+	if !d.leaderElector.IsLeader() {
+		log.Debugf("Skipping scheduled discovery '%s' since this replica is not the leader", schedule.Cron)
+		return
+	}
+	namespaces, err := d.getScheduledNamespaces(schedule)
+	if err != nil {
+		log.Errorf("Scheduled discovery '%s' failed: failed to list namespaces: %s", schedule.Cron, err)
+		return
+	}
+	// System context has no user token, so apihub client uses agent's access token for baseline lookups
+	ctx := secctx.CreateSystemContext()
+	wg := sync.WaitGroup{}
+	for _, ns := range namespaces {
+		namespace := ns
+		wg.Add(1)
+		utils.SafeAsync(func() {
+			defer wg.Done()
+			release, err := d.namespaceLimiter.Acquire(goctx.Background(), namespace)
+			if err != nil {
+				log.Errorf("Failed to start scheduled discovery for namespace %s: %s", namespace, err)
+				return
+			}
+			defer release()
+
+			_, status, _ := d.serviceListCache.GetServicesList(namespace, schedule.WorkspaceId)
+			if status == view.StatusRunning {
+				log.Infof("Skipping scheduled discovery for namespace %s and workspaceId %s since discovery is already running", namespace, schedule.WorkspaceId)
+				return
+			}
+			jobId, err := d.discoveryService.StartDiscovery(ctx, namespace, schedule.WorkspaceId, false, false)
+			if err != nil {
+				log.Errorf("Failed to start scheduled discovery for namespace %s: %s", namespace, err)
+				return
+			}
+			log.Infof("Started scheduled discovery job %s for namespace %s and workspaceId %s", jobId, namespace, schedule.WorkspaceId)
+			d.waitForDiscovery(namespace, schedule.WorkspaceId)
+		})
+	}
+	wg.Wait()
+}
+
+func (d *discoverySchedulerImpl) waitForDiscovery(namespace string, workspaceId string) {
+	ticker := time.NewTicker(scheduledDiscoveryPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, status, _ := d.serviceListCache.GetServicesList(namespace, workspaceId); status != view.StatusRunning {
+			return
+		}
+	}
+}
+
+func (d *discoverySchedulerImpl) getScheduledNamespaces(schedule view.DiscoverySchedule) ([]string, error) {
+	if len(schedule.Namespaces) == 0 {
+		return d.namespaceListCache.ListNamespaces()
+	}
+	for _, ns := range schedule.Namespaces {
+		if ns == view.AllNamespaces {
+			return d.namespaceListCache.ListNamespaces()
+		}
+	}
+	return schedule.Namespaces, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	goctx "context"
+	"fmt"
+	"strings"
+
+	"github.com/Netcracker/qubership-apihub-agent/client"
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/service"
+)
+
+// keys of the discovery Secret data
+const secretTokenKey = "token"
+const secretUsernameKey = "username"
+const secretPasswordKey = "password"
+const secretHeaderKeyPrefix = "header." // e.g. header.X-Api-Key
+
+func getDiscoverySecretName(annotations map[string]string) string {
+	return strings.TrimSpace(annotations[view.CustomK8sDiscoverySecret])
+}
+
+// getDocumentCredentials reads credentials for the service documents from the Secret in the service namespace
+func getDocumentCredentials(ctx goctx.Context, paasClient service.PlatformService, namespace string, secretName string) (*client.DocumentCredentials, error) {
+	secret, err := paasClient.GetSecret(ctx, secretName, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s: %w", secretName, err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("secret %s not found", secretName)
+	}
+	credentials := makeDocumentCredentials(secret.Data)
+	if credentials == nil {
+		return nil, fmt.Errorf("secret %s has neither %s, %s nor %s* keys", secretName, secretTokenKey, secretUsernameKey, secretHeaderKeyPrefix)
+	}
+	return credentials, nil
+}
+
+func makeDocumentCredentials(data map[string][]byte) *client.DocumentCredentials {
+	credentials := client.DocumentCredentials{
+		BearerToken: strings.TrimSpace(string(data[secretTokenKey])),
+		Username:    strings.TrimSpace(string(data[secretUsernameKey])),
+		Password:    strings.TrimSpace(string(data[secretPasswordKey])),
+	}
+	for key, value := range data {
+		if name := strings.TrimPrefix(key, secretHeaderKeyPrefix); name != key && name != "" {
+			if credentials.Headers == nil {
+				credentials.Headers = map[string]string{}
+			}
+			credentials.Headers[name] = strings.TrimSpace(string(value))
+		}
+	}
+	if credentials.BearerToken == "" && credentials.Username == "" && len(credentials.Headers) == 0 {
+		return nil
+	}
+	return &credentials
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeDocumentCredentials(t *testing.T) {
+	credentials := makeDocumentCredentials(map[string][]byte{
+		"username":         []byte("user"),
+		"password":         []byte("pass\n"),
+		"header.X-Api-Key": []byte("key"),
+		"ca.crt":           []byte("ignored"),
+	})
+	assert.NotNil(t, credentials)
+	assert.Equal(t, "user", credentials.Username)
+	assert.Equal(t, "pass", credentials.Password)
+	assert.Equal(t, map[string]string{"X-Api-Key": "key"}, credentials.Headers)
+
+	assert.Nil(t, makeDocumentCredentials(map[string][]byte{"ca.crt": []byte("ignored")}))
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	goctx "context"
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/secctx"
+	"github.com/Netcracker/qubership-apihub-agent/utils"
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/entity"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/filter"
+	pmWatch "github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/watch"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// paas-mediation client has no watch API for deployments, so they are polled
+const deploymentsPollInterval = time.Second * 30
+
+// several events usually arrive for one change (e.g. during rollout), so rediscovery is delayed until events stop
+const serviceRediscoveryDelay = time.Second * 5
+
+// namespaceWatch holds the state of k8s services and deployments watch for one namespace.
+// Changes are applied to the discovery results of all workspaces the namespace was discovered for.
+type namespaceWatch struct {
+	namespace string
+	cancel    goctx.CancelFunc
+
+	mutex                 sync.Mutex
+	workspaceIds          map[string]struct{}
+	serviceVersions       map[string]string
+	deploymentGenerations map[string]int64
+	pendingServices       map[string]*time.Timer
+}
+
+// watchNamespace starts watching the namespace after complete discovery, so changed services are rediscovered without full namespace discovery.
+// Services and deployments listed by the discovery are treated as already discovered.
+func (d *discoveryServiceImpl) watchNamespace(namespace string, workspaceId string, services []entity.Service, deployments []entity.Deployment) {
+	d.namespaceWatchesMutex.Lock()
+	defer d.namespaceWatchesMutex.Unlock()
+
+	w, exists := d.namespaceWatches[namespace]
+	if exists {
+		w.mutex.Lock()
+		w.workspaceIds[workspaceId] = struct{}{}
+		w.setKnownState(services, deployments)
+		w.mutex.Unlock()
+		return
+	}
+
+	ctx, cancel := goctx.WithCancel(goctx.Background())
+	handler, err := d.paasClient.WatchServices(ctx, namespace, filter.Meta{})
+	if err != nil {
+		cancel()
+		log.Errorf("Failed to start k8s services watch in namespace %s: %s", namespace, err)
+		return
+	}
+
+	w = &namespaceWatch{
+		namespace:       namespace,
+		cancel:          cancel,
+		workspaceIds:    map[string]struct{}{workspaceId: {}},
+		pendingServices: map[string]*time.Timer{},
+	}
+	w.setKnownState(services, deployments)
+	d.namespaceWatches[namespace] = w
+
+	log.Infof("Started watching k8s services and deployments in namespace %s", namespace)
+	utils.SafeAsync(func() {
+		d.handleServiceEvents(ctx, w, handler)
+	})
+	utils.SafeAsync(func() {
+		d.pollDeployments(ctx, w)
+	})
+}
+
+func (d *discoveryServiceImpl) stopWatching(w *namespaceWatch) {
+	d.namespaceWatchesMutex.Lock()
+	defer d.namespaceWatchesMutex.Unlock()
+
+	if d.namespaceWatches[w.namespace] == w {
+		delete(d.namespaceWatches, w.namespace)
+		log.Infof("Stopped watching k8s services and deployments in namespace %s", w.namespace)
+	}
+	w.cancel()
+}
+
+func (d *discoveryServiceImpl) handleServiceEvents(ctx goctx.Context, w *namespaceWatch, handler *pmWatch.Handler) {
+	// watch is re-established by the next namespace discovery
+	defer d.stopWatching(w)
+
+	for event := range handler.Channel {
+		if event.Type == pmWatch.Error {
+			log.Warnf("K8s services watch in namespace %s failed: %v", w.namespace, event.Object)
+			continue
+		}
+		srv, ok := event.Object.(*entity.Service)
+		if !ok {
+			log.Debugf("Skipping k8s services watch event %s with unexpected object in namespace %s", event.Type, w.namespace)
+			continue
+		}
+		switch event.Type {
+		case string(watch.Added), string(watch.Modified):
+			if w.updateServiceVersion(srv.Name, srv.ResourceVersion) {
+				log.Debugf("K8s service %s in namespace %s was changed", srv.Name, w.namespace)
+				d.scheduleServiceRediscovery(ctx, w, srv.Name)
+			}
+		case string(watch.Deleted):
+			log.Infof("K8s service %s in namespace %s was deleted", srv.Name, w.namespace)
+			w.mutex.Lock()
+			delete(w.serviceVersions, srv.Name)
+			w.mutex.Unlock()
+			for _, workspaceId := range d.getWatchedWorkspaces(w) {
+				d.serviceListCache.removeService(w.namespace, workspaceId, srv.Name)
+			}
+		}
+	}
+}
+
+func (d *discoveryServiceImpl) pollDeployments(ctx goctx.Context, w *namespaceWatch) {
+	ticker := time.NewTicker(deploymentsPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if len(d.getWatchedWorkspaces(w)) == 0 {
+				log.Infof("Discovery results for namespace %s are expired", w.namespace)
+				d.stopWatching(w)
+				return
+			}
+			deployments, err := d.paasClient.GetDeploymentList(ctx, w.namespace, filter.Meta{})
+			if err != nil {
+				log.Warnf("Failed to list k8s deployments in namespace %s: %s", w.namespace, err)
+				continue
+			}
+			rolledOut := w.getRolledOutDeployments(deployments)
+			if len(rolledOut) == 0 {
+				continue
+			}
+			services, err := d.paasClient.GetServiceList(ctx, w.namespace, filter.Meta{})
+			if err != nil {
+				log.Warnf("Failed to list k8s services in namespace %s: %s", w.namespace, err)
+				continue
+			}
+			for _, deployment := range rolledOut {
+				log.Infof("K8s deployment %s in namespace %s was rolled out", deployment.Name, w.namespace)
+				for _, srv := range services {
+					if len(srv.Spec.Selector) > 0 && labelsMatchSelector(deployment.Labels, srv.Spec.Selector) {
+						d.scheduleServiceRediscovery(ctx, w, srv.Name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (d *discoveryServiceImpl) scheduleServiceRediscovery(ctx goctx.Context, w *namespaceWatch, serviceId string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if timer, exists := w.pendingServices[serviceId]; exists {
+		timer.Reset(serviceRediscoveryDelay)
+		return
+	}
+	w.pendingServices[serviceId] = time.AfterFunc(serviceRediscoveryDelay, func() {
+		w.mutex.Lock()
+		delete(w.pendingServices, serviceId)
+		w.mutex.Unlock()
+
+		utils.SafeAsync(func() {
+			d.rediscoverService(ctx, w, serviceId)
+		})
+	})
+}
+
+// rediscoverService discovers single k8s service and replaces it in complete discovery results of watched workspaces
+func (d *discoveryServiceImpl) rediscoverService(ctx goctx.Context, w *namespaceWatch, serviceId string) {
+	if ctx.Err() != nil {
+		return
+	}
+	workspaceIds := d.getWatchedWorkspaces(w)
+	if len(workspaceIds) == 0 {
+		return
+	}
+
+	srv, labels, annotations, err := d.getServiceDetails(ctx, w.namespace, serviceId)
+	if err != nil {
+		log.Errorf("Failed to rediscover changed service: %s", err)
+		return
+	}
+	if srv == nil {
+		for _, workspaceId := range workspaceIds {
+			d.serviceListCache.removeService(w.namespace, workspaceId, serviceId)
+		}
+		return
+	}
+	excluded := d.isExcluded(serviceId, labels)
+	bgSiblingIds := d.getBlueGreenSiblingIds(ctx, w.namespace, serviceId)
+
+	// there's no user to take the token from, so the agent's access token is used for baseline lookup
+	secCtx := secctx.CreateSystemContext()
+	// documents don't depend on the workspace, so the service is discovered once and only the baseline is looked up for each workspace
+	var discoveredService *view.Service
+	for _, workspaceId := range workspaceIds {
+		_, status, _ := d.serviceListCache.GetServicesList(w.namespace, workspaceId)
+		if status != view.StatusComplete {
+			// running discovery will get the actual state, failed or cancelled results are not updated
+			continue
+		}
+		if excluded {
+			d.serviceListCache.removeService(w.namespace, workspaceId, serviceId)
+			continue
+		}
+		log.Infof("Rediscovering changed service %s in namespace %s for workspaceId %s", serviceId, w.namespace, workspaceId)
+		if discoveredService == nil {
+			discoveredService = d.discoverService(ctx, secCtx, w.namespace, workspaceId, *srv, labels, annotations, bgSiblingIds)
+			if discoveredService == nil {
+				continue
+			}
+			d.serviceListCache.updateService(w.namespace, workspaceId, *discoveredService)
+			continue
+		}
+		workspaceService := *discoveredService
+		workspaceService.Baseline = d.getBaseline(ctx, secCtx, workspaceId, workspaceService.Name)
+		d.serviceListCache.updateService(w.namespace, workspaceId, workspaceService)
+	}
+}
+
+// getWatchedWorkspaces returns workspaces which still have discovery results for the namespace. Workspaces with expired results are not watched anymore.
+func (d *discoveryServiceImpl) getWatchedWorkspaces(w *namespaceWatch) []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	result := make([]string, 0, len(w.workspaceIds))
+	for workspaceId := range w.workspaceIds {
+		_, status, _ := d.serviceListCache.GetServicesList(w.namespace, workspaceId)
+		if status == view.StatusNone {
+			delete(w.workspaceIds, workspaceId)
+			continue
+		}
+		result = append(result, workspaceId)
+	}
+	return result
+}
+
+// setKnownState must be called under the lock
+func (w *namespaceWatch) setKnownState(services []entity.Service, deployments []entity.Deployment) {
+	w.serviceVersions = make(map[string]string, len(services))
+	for _, srv := range services {
+		w.serviceVersions[srv.Name] = srv.ResourceVersion
+	}
+	w.deploymentGenerations = make(map[string]int64, len(deployments))
+	for _, deployment := range deployments {
+		// deployment which is not rolled out yet is reported once it's rolled out, so its services are rediscovered
+		if isDeploymentRolledOut(deployment) {
+			w.deploymentGenerations[deployment.Name] = deployment.Generation
+		}
+	}
+}
+
+// updateServiceVersion returns true if the service was changed since it was discovered
+func (w *namespaceWatch) updateServiceVersion(serviceId string, resourceVersion string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if knownVersion, exists := w.serviceVersions[serviceId]; exists && knownVersion == resourceVersion {
+		return false
+	}
+	w.serviceVersions[serviceId] = resourceVersion
+	return true
+}
+
+// getRolledOutDeployments returns deployments which completed rollout of the new generation since the previous check
+func (w *namespaceWatch) getRolledOutDeployments(deployments []entity.Deployment) []entity.Deployment {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	var result []entity.Deployment
+	for _, deployment := range deployments {
+		if !isDeploymentRolledOut(deployment) {
+			continue
+		}
+		if knownGeneration, exists := w.deploymentGenerations[deployment.Name]; exists && knownGeneration == deployment.Generation {
+			continue
+		}
+		w.deploymentGenerations[deployment.Name] = deployment.Generation
+		result = append(result, deployment)
+	}
+	return result
+}
+
+func isDeploymentRolledOut(deployment entity.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.Replicas == replicas &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.ReadyReplicas == replicas
+}
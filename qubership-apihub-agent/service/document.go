@@ -1,32 +1,38 @@
 package service
 
 import (
+	goctx "context"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/Netcracker/qubership-apihub-agent/client"
 	"github.com/Netcracker/qubership-apihub-agent/exception"
+	"github.com/Netcracker/qubership-apihub-agent/utils"
 	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/service"
 )
 
 type DocumentService interface {
-	GetDocumentById(namespace, workspaceId, serviceId, fileId string) ([]byte, error)
+	GetDocumentById(ctx goctx.Context, namespace, workspaceId, serviceId, fileId string) ([]byte, error)
 }
 
-func NewDocumentService(servicesListCache ServiceListCache, getDocTimeout time.Duration) DocumentService {
-	return &documentServiceImpl{servicesListCache: servicesListCache, getDocTimeout: getDocTimeout}
+func NewDocumentService(servicesListCache ServiceListCache, getDocTimeout time.Duration, paasClient service.PlatformService) DocumentService {
+	return &documentServiceImpl{servicesListCache: servicesListCache, getDocTimeout: getDocTimeout, paasClient: paasClient}
 }
 
 type documentServiceImpl struct {
 	servicesListCache ServiceListCache
 	getDocTimeout     time.Duration
+	paasClient        service.PlatformService
 }
 
-func (d documentServiceImpl) GetDocumentById(namespace, workspaceId, serviceId, fileId string) ([]byte, error) {
+func (d documentServiceImpl) GetDocumentById(ctx goctx.Context, namespace, workspaceId, serviceId, fileId string) ([]byte, error) {
 	var svc view.Service
 	var relPath string
 	var documentType string
 	var format string
+	var baseUrl string
 
 	slist, _, _ := d.servicesListCache.GetServicesList(namespace, workspaceId)
 	for _, svcIt := range slist {
@@ -40,6 +46,7 @@ func (d documentServiceImpl) GetDocumentById(namespace, workspaceId, serviceId,
 			relPath = document.DocPath
 			documentType = document.Type
 			format = document.Format
+			baseUrl = document.BaseUrl
 			break
 		}
 	}
@@ -53,23 +60,53 @@ func (d documentServiceImpl) GetDocumentById(namespace, workspaceId, serviceId,
 		}
 	}
 
-	specUrl := svc.Url + relPath
+	if svc.DiscoverySecret != "" {
+		credentials, err := getDocumentCredentials(ctx, d.paasClient, namespace, svc.DiscoverySecret)
+		if err != nil {
+			return nil, &exception.CustomError{
+				Status:  http.StatusFailedDependency,
+				Code:    exception.DiscoverySecretNotAvailable,
+				Message: exception.DiscoverySecretNotAvailableMsg,
+				Params:  map[string]interface{}{"secret": svc.DiscoverySecret, "serviceId": serviceId, "namespace": namespace},
+				Debug:   err.Error(),
+			}
+		}
+		ctx = client.WithDocumentCredentials(ctx, credentials)
+	}
+
+	// the user is waiting for the document, so it's not queued behind background discovery requests
+	ctx = utils.WithUserRequest(ctx)
+	if baseUrl == "" {
+		// document of the service discovered before base urls were stored
+		baseUrl = svc.Url
+	}
+	specUrl := baseUrl + relPath
 
+	return getDocumentContent(ctx, specUrl, documentType, format, d.getDocTimeout)
+}
+
+// getDocumentContent downloads the document the way it's expected by its type and format
+func getDocumentContent(ctx goctx.Context, specUrl string, documentType string, format string, timeout time.Duration) ([]byte, error) {
 	var content []byte
 	var err error
 	switch documentType {
 	case view.OpenAPI20Type, view.OpenAPI30Type, view.OpenAPI31Type:
-		content, err = client.GetRawDocumentFromUrl(specUrl, string(view.ATRest), d.getDocTimeout)
+		content, err = client.GetRawDocumentFromUrl(ctx, specUrl, string(view.ATRest), timeout)
 	case view.GraphQLType:
 		if format == "json" {
-			content, err = client.GetRawGraphqlIntrospectionFromUrl(specUrl, d.getDocTimeout)
+			content, err = client.GetRawGraphqlIntrospectionFromUrl(ctx, specUrl, timeout)
 		} else {
-			content, err = client.GetRawDocumentFromUrl(specUrl, string(view.ATGraphql), d.getDocTimeout)
+			content, err = client.GetRawDocumentFromUrl(ctx, specUrl, string(view.ATGraphql), timeout)
 		}
 	default:
-		content, err = client.GetRawDocumentFromUrl(specUrl, documentType, d.getDocTimeout)
+		content, err = client.GetRawDocumentFromUrl(ctx, specUrl, documentType, timeout)
 	}
 	if err != nil {
+		// retry history is needed for discovery diagnostics only, the error of the last attempt is returned
+		var retriesErr *client.RetriesExhaustedError
+		if errors.As(err, &retriesErr) {
+			return nil, retriesErr.Err
+		}
 		return nil, err
 	}
 	return content, nil
@@ -1,9 +1,13 @@
 package service
 
 import (
+	goctx "context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
 	"sync"
 
 	"time"
@@ -15,13 +19,15 @@ import (
 	"github.com/Netcracker/qubership-apihub-agent/api_type/rest"
 	"github.com/Netcracker/qubership-apihub-agent/api_type/smartplug"
 	"github.com/Netcracker/qubership-apihub-agent/api_type/unknown"
+	"github.com/Netcracker/qubership-apihub-agent/client"
 	"github.com/Netcracker/qubership-apihub-agent/utils"
 	"github.com/Netcracker/qubership-apihub-agent/view"
 	log "github.com/sirupsen/logrus"
 )
 
 type DocumentsDiscoveryService interface {
-	RetrieveDocuments(baseUrl string, serviceName string, urls view.DocumentDiscoveryUrls) (*view.DiscoveryResult, error)
+	RetrieveDocuments(ctx goctx.Context, baseUrl string, serviceName string, urls view.DocumentDiscoveryUrls) (*view.DiscoveryResult, error)
+	GetRunnerNames(kind view.DiscoveryUrlKind) []string
 }
 
 const ConfigUrlField = "url"
@@ -50,14 +56,18 @@ type documentsDiscoveryServiceImpl struct {
 	discoveryTimeout time.Duration
 }
 
-func (d documentsDiscoveryServiceImpl) RetrieveDocuments(baseUrl string, serviceName string, urls view.DocumentDiscoveryUrls) (*view.DiscoveryResult, error) {
+func (d documentsDiscoveryServiceImpl) RetrieveDocuments(ctx goctx.Context, baseUrl string, serviceName string, urls view.DocumentDiscoveryUrls) (*view.DiscoveryResult, error) {
+	ctx, retriedCalls := client.WithRetriedCalls(ctx)
+
 	// check apihub config first
 	var refsFromApihubConfig []view.DocumentRef
 
-	apihubConfig, configPath, apihubConfigCallResults := getApihubConfigFromUrls(baseUrl, urls.ApihubConfig, d.discoveryTimeout)
+	apihubConfig, configPath, apihubConfigCallResults := getApihubConfigFromUrls(ctx, baseUrl, urls.ApihubConfig, d.discoveryTimeout)
 	if apihubConfig != nil {
 		refsFromApihubConfig = getDocumentRefsFromApihubConfig(apihubConfig, d.discoveryTimeout*3) // We know that this endpoint should contain the spec, so it's not a guess, increase timeout
 	}
+	// documents listed in the service annotation are requested in addition to the discovered ones
+	refsFromAnnotation := urls.Config.MakeDocumentRefs(d.discoveryTimeout * 3)
 
 	// process each supported type in parallel
 	docsByRunners := map[int][]view.Document{}
@@ -77,12 +87,26 @@ func (d documentsDiscoveryServiceImpl) RetrieveDocuments(baseUrl string, service
 
 			var docs []view.Document
 			var callResults []view.EndpointCallInfo
-			var err error
+			var refsErr error
 
+			if len(refsFromAnnotation) > 0 {
+				docs, callResults, refsErr = runner.GetDocumentsByRefs(ctx, baseUrl, refsFromAnnotation, "")
+			}
+			// failed annotation document doesn't prevent discovery of the other ones
+			var discoveredDocs []view.Document
+			var discoveryCallResults []view.EndpointCallInfo
+			var err error
 			if len(refsFromApihubConfig) > 0 {
-				docs, callResults, err = runner.GetDocumentsByRefs(baseUrl, refsFromApihubConfig, configPath) // just get documents from known urls
+				discoveredDocs, discoveryCallResults, err = runner.GetDocumentsByRefs(ctx, baseUrl, refsFromApihubConfig, configPath) // just get documents from known urls
 			} else {
-				docs, callResults, err = runner.DiscoverDocuments(baseUrl, urls, d.discoveryTimeout)
+				discoveredDocs, discoveryCallResults, err = runner.DiscoverDocuments(ctx, baseUrl, urls, d.discoveryTimeout)
+			}
+			docs = append(docs, discoveredDocs...)
+			callResults = append(callResults, discoveryCallResults...)
+			if refsErr != nil && err != nil {
+				err = fmt.Errorf("%s | %s", refsErr, err)
+			} else if refsErr != nil {
+				err = refsErr
 			}
 
 			docsMutex.Lock()
@@ -106,14 +130,34 @@ func (d documentsDiscoveryServiceImpl) RetrieveDocuments(baseUrl string, service
 		resultCalls = append(resultCalls, callsByRunners[i]...)
 	}
 
-	resultDocs = removeDuplicateDocuments(resultDocs) // TODO: required or not???
+	resultDocs = makeUniqueFileIds(removeDuplicateDocuments(resultDocs)) // TODO: required or not???
+
+	resultRetriedCalls := retriedCalls.Get()
+	for i := range resultRetriedCalls {
+		resultRetriedCalls[i].Path = strings.TrimPrefix(resultRetriedCalls[i].Path, baseUrl)
+	}
 
 	return &view.DiscoveryResult{
 		Documents:     resultDocs,
 		EndpointCalls: resultCalls,
+		RetriedCalls:  resultRetriedCalls,
 	}, utils.FilterResultErrorsMap(errsByRunners)
 }
 
+// GetRunnerNames returns names of the runners which probe urls of the kind. Apihub config urls are probed before all runners, so no runner is returned for them.
+func (d documentsDiscoveryServiceImpl) GetRunnerNames(kind view.DiscoveryUrlKind) []string {
+	result := make([]string, 0)
+	for _, runner := range d.runners {
+		for _, runnerKind := range runner.GetDiscoveryUrlKinds() {
+			if runnerKind == kind {
+				result = append(result, runner.GetName())
+				break
+			}
+		}
+	}
+	return result
+}
+
 func removeDuplicateDocuments(specs []view.Document) []view.Document {
 	result := make([]view.Document, 0)
 	uniqueIds := make(map[string]string)
@@ -127,6 +171,16 @@ func removeDuplicateDocuments(specs []view.Document) []view.Document {
 	return result
 }
 
+// makeUniqueFileIds renames documents with the same file id, since file ids are generated separately by each runner call
+func makeUniqueFileIds(documents []view.Document) []view.Document {
+	fileIds := sync.Map{}
+	for i := range documents {
+		extension := path.Ext(documents[i].FileId)
+		documents[i].FileId = utils.GenerateFileId(&fileIds, strings.TrimSuffix(documents[i].FileId, extension), strings.TrimPrefix(extension, "."))
+	}
+	return documents
+}
+
 func getDocumentRefsFromApihubConfig(apihubConfig view.JsonMap, timeout time.Duration) []view.DocumentRef {
 	documentRefs := make([]view.DocumentRef, 0)
 	if apihubConfig == nil {
@@ -171,14 +225,23 @@ func getDocumentRefsFromApihubConfig(apihubConfig view.JsonMap, timeout time.Dur
 	return documentRefs
 }
 
-func getApihubConfigFromUrls(baseUrl string, paths []string, timeout time.Duration) (view.JsonMap, string, []view.EndpointCallInfo) {
-	client := utils.MakeDiscoveryHttpClient(timeout)
+func getApihubConfigFromUrls(ctx goctx.Context, baseUrl string, paths []string, timeout time.Duration) (view.JsonMap, string, []view.EndpointCallInfo) {
+	httpClient := utils.MakeDiscoveryHttpClient(timeout)
 	var callResults []view.EndpointCallInfo
 
 	for _, path := range paths {
 		url := baseUrl + path
 		log.Debugf("Trying to get apihub config from url: %s", url)
-		resp, err := client.Get(url)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			callResults = append(callResults, view.EndpointCallInfo{
+				Path:         path,
+				ErrorSummary: fmt.Sprintf("Failed to get APIHUB config: %s", err.Error()),
+			})
+			continue
+		}
+		client.AddDocumentCredentials(req)
+		resp, err := httpClient.Do(req)
 		if err != nil {
 			callResults = append(callResults, view.EndpointCallInfo{
 				Path:         path,
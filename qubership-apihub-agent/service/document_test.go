@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/utils"
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDocumentByIdUsesSchemeOfDocumentPort(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("http document"))
+	}))
+	defer httpServer.Close()
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("https document"))
+	}))
+	defer tlsServer.Close()
+
+	caBundlePath := filepath.Join(t.TempDir(), "ca.crt")
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caBundlePath, caBundle, 0600))
+	assert.NoError(t, utils.SetDiscoveryTlsConfig(caBundlePath, "", "", false))
+	defer func() { _ = utils.SetDiscoveryTlsConfig("", "", "", false) }()
+
+	cache := NewServiceListCache(time.Hour)
+	cache.handleDiscoveryStart("ns", "ws", "job")
+	cache.addService("ns", "ws", "job", view.Service{
+		Id:  "svc",
+		Url: httpServer.URL, // primary port is the plain one
+		Documents: []view.Document{
+			{FileId: "http.md", Type: view.MDType, Format: "md", DocPath: "/doc", BaseUrl: httpServer.URL},
+			{FileId: "https.md", Type: view.MDType, Format: "md", DocPath: "/doc", BaseUrl: tlsServer.URL},
+		},
+	})
+	cache.setResultStatus("ns", "ws", "job", view.StatusComplete, "")
+	documentService := NewDocumentService(cache, time.Second, nil)
+
+	content, err := documentService.GetDocumentById(context.Background(), "ns", "ws", "svc", "http.md")
+	assert.NoError(t, err)
+	assert.Equal(t, "http document", string(content))
+	content, err = documentService.GetDocumentById(context.Background(), "ns", "ws", "svc", "https.md")
+	assert.NoError(t, err)
+	assert.Equal(t, "https document", string(content))
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	goctx "context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/entity"
+	"github.com/shaj13/libcache"
+	_ "github.com/shaj13/libcache/lru"
+	log "github.com/sirupsen/logrus"
+)
+
+const externalNameServiceType = "ExternalName"
+
+const externalHostLookupTimeout = 5 * time.Second
+
+// externalName target of the service may change, so it's resolved again after the ttl
+const externalHostTtl = 5 * time.Minute
+
+var resolvedExternalHosts = newResolvedExternalHosts()
+
+var lookupCNAME = net.DefaultResolver.LookupCNAME
+
+func newResolvedExternalHosts() libcache.Cache {
+	cache := libcache.LRU.New(0)
+	cache.SetTTL(externalHostTtl)
+	return cache
+}
+
+// isExternalService checks if the service only points to the host outside the cluster, e.g. to a managed service
+func isExternalService(srv entity.Service) bool {
+	return srv.Spec.Type == externalNameServiceType
+}
+
+func getClusterLocalHost(srv entity.Service) string {
+	return srv.Name + "." + srv.Namespace + ".svc.cluster.local"
+}
+
+// getServiceHost returns the host the service documents are requested from
+func getServiceHost(srv entity.Service) string {
+	if isExternalService(srv) {
+		return getExternalHost(srv)
+	}
+	return getClusterLocalHost(srv)
+}
+
+// getExternalHost returns the host from the annotation or the externalName target resolved by resolveExternalHosts
+func getExternalHost(srv entity.Service) string {
+	if host := strings.TrimSpace(srv.Annotations[view.CustomK8sExternalHost]); host != "" {
+		return host
+	}
+	if host, exists := resolvedExternalHosts.Peek(makeExternalHostKey(srv)); exists {
+		return host.(string)
+	}
+	return getClusterLocalHost(srv)
+}
+
+// resolveExternalHosts resolves externalName targets of the services without the host annotation via the cluster DNS,
+// since the platform client does not expose the service spec.externalName field. Targets are cached for externalHostTtl,
+// so the urls of the services are built without DNS requests.
+func resolveExternalHosts(ctx goctx.Context, services []entity.Service) {
+	for _, srv := range services {
+		if !isExternalService(srv) || strings.TrimSpace(srv.Annotations[view.CustomK8sExternalHost]) != "" {
+			continue
+		}
+		key := makeExternalHostKey(srv)
+		if resolvedExternalHosts.Contains(key) {
+			continue
+		}
+		host, resolved := lookupExternalHost(ctx, srv)
+		if !resolved {
+			// discovery is cancelled, the target is resolved next time
+			return
+		}
+		resolvedExternalHosts.Store(key, host)
+	}
+}
+
+// lookupExternalHost returns the externalName target of the service or the cluster local host if it can't be resolved.
+// Returns false if the context is cancelled.
+func lookupExternalHost(ctx goctx.Context, srv entity.Service) (string, bool) {
+	clusterLocalHost := getClusterLocalHost(srv)
+	lookupCtx, cancel := goctx.WithTimeout(ctx, externalHostLookupTimeout)
+	defer cancel()
+	target, err := lookupCNAME(lookupCtx, clusterLocalHost)
+	if ctx.Err() != nil {
+		return "", false
+	}
+	target = strings.TrimSuffix(target, ".")
+	if err != nil || target == "" || target == clusterLocalHost {
+		log.Warnf("Failed to resolve externalName of service %s, set %s annotation to discover it: %v", srv.Name, view.CustomK8sExternalHost, err)
+		return clusterLocalHost, true
+	}
+	return target, true
+}
+
+func makeExternalHostKey(srv entity.Service) string {
+	return srv.Namespace + "/" + srv.Name
+}
+
+// getExternalPort returns the port from the annotation. Returns nil if it's not set.
+func getExternalPort(srv entity.Service) *entity.Port {
+	value := strings.TrimSpace(srv.Annotations[view.CustomK8sExternalPort])
+	if value == "" {
+		return nil
+	}
+	port, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || port <= 0 {
+		log.Warnf("Invalid port '%s' in annotation %s of service %s", value, view.CustomK8sExternalPort, srv.Name)
+		return nil
+	}
+	return &entity.Port{Port: int32(port), Protocol: "TCP"}
+}
+
+// getExternalScheme returns the scheme from the annotation. Returns empty string if it's not set.
+func getExternalScheme(srv entity.Service) string {
+	value := strings.ToLower(strings.TrimSpace(srv.Annotations[view.CustomK8sExternalScheme]))
+	if value == "http" || value == "https" {
+		return value
+	}
+	if value != "" {
+		log.Warnf("Invalid scheme '%s' in annotation %s of service %s, expected http or https", value, view.CustomK8sExternalScheme, srv.Name)
+	}
+	return ""
+}
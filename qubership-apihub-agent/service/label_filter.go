@@ -0,0 +1,57 @@
+package service
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// LabelFilter decides which services take part in discovery by their full list of labels (service and pods labels).
+// Rules use k8s label selector syntax: key, !key, key=value, key!=value, key in (a,b), key notin (a,b).
+type LabelFilter interface {
+	// Excluded returns true and the rule which excluded the service if labels don't pass the filter
+	Excluded(serviceLabels map[string]string) (bool, string)
+}
+
+// NewLabelFilter creates filter from comma-separated include and exclude rules.
+// Service must match all include rules and must not match any of exclude rules.
+func NewLabelFilter(includeRules string, excludeRules string) (LabelFilter, error) {
+	include, err := parseLabelRules(includeRules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include label rules '%s': %w", includeRules, err)
+	}
+	exclude, err := parseLabelRules(excludeRules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude label rules '%s': %w", excludeRules, err)
+	}
+	return labelFilterImpl{include: include, exclude: exclude}, nil
+}
+
+type labelFilterImpl struct {
+	include labels.Requirements
+	exclude labels.Requirements
+}
+
+func (f labelFilterImpl) Excluded(serviceLabels map[string]string) (bool, string) {
+	set := labels.Set(serviceLabels)
+	for _, rule := range f.include {
+		if !rule.Matches(set) {
+			return true, rule.String()
+		}
+	}
+	for _, rule := range f.exclude {
+		if rule.Matches(set) {
+			return true, rule.String()
+		}
+	}
+	return false, ""
+}
+
+func parseLabelRules(rules string) (labels.Requirements, error) {
+	selector, err := labels.Parse(rules)
+	if err != nil {
+		return nil, err
+	}
+	requirements, _ := selector.Requirements()
+	return requirements, nil
+}
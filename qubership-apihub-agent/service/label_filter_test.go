@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelFilterExcluded(t *testing.T) {
+	filter, err := NewLabelFilter("app.kubernetes.io/part-of in (shop,billing),!internal", "gateway,tier=batch")
+	assert.NoError(t, err)
+
+	excluded, _ := filter.Excluded(map[string]string{"app.kubernetes.io/part-of": "shop"})
+	assert.False(t, excluded)
+
+	excluded, rule := filter.Excluded(map[string]string{"app.kubernetes.io/part-of": "crm"})
+	assert.True(t, excluded)
+	assert.Equal(t, "app.kubernetes.io/part-of in (billing,shop)", rule)
+
+	excluded, rule = filter.Excluded(map[string]string{"app.kubernetes.io/part-of": "shop", "internal": "true"})
+	assert.True(t, excluded)
+	assert.Equal(t, "!internal", rule)
+
+	excluded, rule = filter.Excluded(map[string]string{"app.kubernetes.io/part-of": "billing", "gateway": ""})
+	assert.True(t, excluded)
+	assert.Equal(t, "gateway", rule)
+
+	excluded, _ = filter.Excluded(map[string]string{"app.kubernetes.io/part-of": "billing", "tier": "web"})
+	assert.False(t, excluded)
+}
+
+func TestLabelFilterEmptyRules(t *testing.T) {
+	filter, err := NewLabelFilter("", "")
+	assert.NoError(t, err)
+	excluded, _ := filter.Excluded(map[string]string{"any": "label"})
+	assert.False(t, excluded)
+
+	_, err = NewLabelFilter("", "key in (a")
+	assert.Error(t, err)
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/utils"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/entity"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/service"
+	log "github.com/sirupsen/logrus"
+)
+
+// LeaderElector elects a single Agent replica to run scheduled and all namespaces discovery
+type LeaderElector interface {
+	RunLeaderElection()
+	IsLeader() bool
+	// GetLeaderAddress returns URL of the leader replica. Empty if the leader is not known yet.
+	GetLeaderAddress() string
+}
+
+const leaderLeaseDuration = 30 * time.Second
+const leaderRenewInterval = 10 * time.Second
+
+// leader keeps the leadership until the renew deadline if the lock is not available, so it stops before other replica can take over
+const leaderRenewDeadline = 20 * time.Second
+
+const leaderLockName = "qubership-apihub-agent-leader"
+const leaderRecordKey = "leader"
+
+// NewLeaderElector creates the elector which uses ConfigMap in the Agent namespace as the lock, or the lock file if lockFile is set.
+// If election is disabled, the replica is always the leader.
+func NewLeaderElector(enabled bool, paasClient service.PlatformService, namespace string, lockFile string, identity string, address string) LeaderElector {
+	elector := &leaderElectorImpl{identity: identity, address: address}
+	if !enabled {
+		elector.leader = true
+		elector.leaderAddress = address
+		return elector
+	}
+	if lockFile != "" {
+		elector.lock = &fileLeaderLock{path: lockFile}
+	} else {
+		elector.lock = &configMapLeaderLock{paasClient: paasClient, namespace: namespace, name: leaderLockName}
+	}
+	return elector
+}
+
+type leaderRecord struct {
+	HolderIdentity string    `json:"holderIdentity"`
+	HolderAddress  string    `json:"holderAddress"`
+	RenewTime      time.Time `json:"renewTime"`
+}
+
+func (r leaderRecord) equals(other leaderRecord) bool {
+	return r.HolderIdentity == other.HolderIdentity && r.HolderAddress == other.HolderAddress && r.RenewTime.Equal(other.RenewTime)
+}
+
+// leaderLock stores the leader record with optimistic concurrency
+type leaderLock interface {
+	// get returns current leader record and its version. Nil record is returned if there's no leader yet.
+	get(ctx context.Context) (*leaderRecord, string, error)
+	// update replaces the record of the given version or creates it if the version is empty. Fails if the record was concurrently modified.
+	update(ctx context.Context, record leaderRecord, version string) error
+}
+
+type leaderElectorImpl struct {
+	lock     leaderLock // nil if election is disabled
+	identity string
+	address  string
+
+	mutex         sync.RWMutex
+	leader        bool
+	leaderAddress string
+
+	// Expiration is measured by local clock since the record change was observed, so clocks of replicas don't have to be in sync
+	observedRecord leaderRecord
+	observedTime   time.Time
+	lastRenewTime  time.Time
+}
+
+func (l *leaderElectorImpl) RunLeaderElection() {
+	if l.lock == nil {
+		log.Info("Leader election is disabled")
+		return
+	}
+	log.Infof("Starting leader election for %s", l.identity)
+	utils.SafeAsync(func() {
+		for {
+			l.tryAcquireOrRenew()
+			time.Sleep(leaderRenewInterval)
+		}
+	})
+}
+
+func (l *leaderElectorImpl) IsLeader() bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.leader
+}
+
+func (l *leaderElectorImpl) GetLeaderAddress() string {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.leaderAddress
+}
+
+func (l *leaderElectorImpl) tryAcquireOrRenew() {
+	ctx, cancel := context.WithTimeout(context.Background(), leaderRenewInterval)
+	defer cancel()
+	now := time.Now()
+
+	current, version, err := l.lock.get(ctx)
+	if err != nil {
+		log.Errorf("Failed to get leader record: %s", err)
+		l.handleRenewFailure(now)
+		return
+	}
+	if current != nil {
+		if !current.equals(l.observedRecord) {
+			l.observedRecord = *current
+			l.observedTime = now
+		}
+		if current.HolderIdentity != l.identity && now.Sub(l.observedTime) < leaderLeaseDuration {
+			l.setLeader(false, current.HolderAddress)
+			return
+		}
+	}
+
+	record := leaderRecord{HolderIdentity: l.identity, HolderAddress: l.address, RenewTime: now}
+	if err := l.lock.update(ctx, record, version); err != nil {
+		// most likely other replica took the lock first
+		log.Debugf("Failed to update leader record: %s", err)
+		l.handleRenewFailure(now)
+		return
+	}
+	l.observedRecord = record
+	l.observedTime = now
+	l.lastRenewTime = now
+	l.setLeader(true, l.address)
+}
+
+func (l *leaderElectorImpl) handleRenewFailure(now time.Time) {
+	if l.IsLeader() && now.Sub(l.lastRenewTime) >= leaderRenewDeadline {
+		l.setLeader(false, "")
+	}
+}
+
+func (l *leaderElectorImpl) setLeader(leader bool, leaderAddress string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.leader != leader {
+		if leader {
+			log.Infof("%s became the leader", l.identity)
+		} else {
+			log.Infof("%s is not the leader anymore", l.identity)
+		}
+	}
+	l.leader = leader
+	l.leaderAddress = leaderAddress
+}
+
+// configMapLeaderLock keeps the record in ConfigMap. Resource version of the ConfigMap is used as the record version.
+type configMapLeaderLock struct {
+	paasClient service.PlatformService
+	namespace  string
+	name       string
+}
+
+func (c *configMapLeaderLock) get(ctx context.Context) (*leaderRecord, string, error) {
+	configMap, err := c.paasClient.GetConfigMap(ctx, c.name, c.namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	if configMap == nil {
+		return nil, "", nil
+	}
+	record := &leaderRecord{}
+	if data := configMap.Data[leaderRecordKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), record); err != nil {
+			log.Errorf("Failed to parse leader record from ConfigMap %s: %s", c.name, err)
+		}
+	}
+	return record, configMap.ResourceVersion, nil
+}
+
+func (c *configMapLeaderLock) update(ctx context.Context, record leaderRecord, version string) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	configMap := &entity.ConfigMap{
+		Metadata: entity.Metadata{Name: c.name, Namespace: c.namespace, ResourceVersion: version},
+		Data:     map[string]string{leaderRecordKey: string(data)},
+	}
+	if version == "" {
+		_, err = c.paasClient.CreateConfigMap(ctx, configMap, c.namespace)
+	} else {
+		// update is rejected by Kubernetes if the resource version is outdated
+		_, err = c.paasClient.UpdateOrCreateConfigMap(ctx, configMap, c.namespace)
+	}
+	return err
+}
+
+// fileLeaderLock keeps the record in the local file for local runs without Kubernetes. File content is used as the record version.
+// Check of the version and the write are not atomic, but the gap is negligible for replicas started on the same machine.
+type fileLeaderLock struct {
+	path string
+}
+
+func (f *fileLeaderLock) get(_ context.Context) (*leaderRecord, string, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	record := &leaderRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		log.Errorf("Failed to parse leader record from %s: %s", f.path, err)
+	}
+	return record, string(data), nil
+}
+
+func (f *fileLeaderLock) update(_ context.Context, record leaderRecord, version string) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = file.Write(data)
+		return err
+	}
+	current, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	if string(current) != version {
+		return fmt.Errorf("leader record in %s was concurrently modified", f.path)
+	}
+	tmpFile := filepath.Join(filepath.Dir(f.path), fmt.Sprintf(".%s.%d", filepath.Base(f.path), os.Getpid()))
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, f.path)
+}
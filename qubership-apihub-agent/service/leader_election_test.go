@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLeaderLockElectsSingleLeader(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), "leader.json")
+	first := NewLeaderElector(true, nil, "", lockFile, "first", "http://first:8080").(*leaderElectorImpl)
+	second := NewLeaderElector(true, nil, "", lockFile, "second", "http://second:8080").(*leaderElectorImpl)
+
+	first.tryAcquireOrRenew()
+	second.tryAcquireOrRenew()
+	assert.True(t, first.IsLeader())
+	assert.False(t, second.IsLeader())
+	assert.Equal(t, "http://first:8080", second.GetLeaderAddress())
+
+	// leader keeps the lock on renew
+	first.tryAcquireOrRenew()
+	second.tryAcquireOrRenew()
+	assert.True(t, first.IsLeader())
+	assert.False(t, second.IsLeader())
+}
+
+func TestFileLeaderLockTakeoverAfterLeaseExpires(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), "leader.json")
+	first := NewLeaderElector(true, nil, "", lockFile, "first", "http://first:8080").(*leaderElectorImpl)
+	second := NewLeaderElector(true, nil, "", lockFile, "second", "http://second:8080").(*leaderElectorImpl)
+
+	first.tryAcquireOrRenew()
+	second.tryAcquireOrRenew()
+	assert.False(t, second.IsLeader())
+
+	// first replica stopped renewing the lock, the lease is measured from the time second one observed the record
+	second.observedTime = second.observedTime.Add(-leaderLeaseDuration / 2)
+	second.tryAcquireOrRenew()
+	assert.False(t, second.IsLeader())
+
+	second.observedTime = second.observedTime.Add(-leaderLeaseDuration)
+	second.tryAcquireOrRenew()
+	assert.True(t, second.IsLeader())
+	assert.Equal(t, "http://second:8080", second.GetLeaderAddress())
+
+	// former leader finds out about the new one on the next renew
+	first.tryAcquireOrRenew()
+	assert.False(t, first.IsLeader())
+	assert.Equal(t, "http://second:8080", first.GetLeaderAddress())
+}
+
+func TestLeaderStepsDownAfterRenewDeadline(t *testing.T) {
+	lock := &failingLeaderLock{}
+	elector := &leaderElectorImpl{lock: lock, identity: "first", address: "http://first:8080"}
+
+	elector.tryAcquireOrRenew()
+	assert.True(t, elector.IsLeader())
+
+	lock.failing = true
+	elector.lastRenewTime = time.Now().Add(-leaderRenewDeadline / 2)
+	elector.tryAcquireOrRenew()
+	assert.True(t, elector.IsLeader())
+
+	elector.lastRenewTime = time.Now().Add(-leaderRenewDeadline)
+	elector.tryAcquireOrRenew()
+	assert.False(t, elector.IsLeader())
+}
+
+// failingLeaderLock keeps the record in memory and fails all requests when failing is set
+type failingLeaderLock struct {
+	record  *leaderRecord
+	version int
+	failing bool
+}
+
+func (f *failingLeaderLock) get(_ context.Context) (*leaderRecord, string, error) {
+	if f.failing {
+		return nil, "", errors.New("lock is not available")
+	}
+	if f.record == nil {
+		return nil, "", nil
+	}
+	record := *f.record
+	return &record, string(rune('0' + f.version)), nil
+}
+
+func (f *failingLeaderLock) update(_ context.Context, record leaderRecord, _ string) error {
+	if f.failing {
+		return errors.New("lock is not available")
+	}
+	f.record = &record
+	f.version++
+	return nil
+}
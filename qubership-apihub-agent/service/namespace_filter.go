@@ -0,0 +1,73 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NamespaceFilter decides which namespaces are visible to the agent by namespace name and labels.
+type NamespaceFilter interface {
+	Excluded(namespace string, namespaceLabels map[string]string) bool
+}
+
+// NewNamespaceFilter creates filter from comma-separated lists of name regexes and label rules.
+// Regexes must match the whole namespace name. Namespace must match any of include regexes and none of exclude regexes,
+// label rules are applied the same way as for services (see NewLabelFilter).
+func NewNamespaceFilter(includePatterns string, excludePatterns string, includeLabels string, excludeLabels string) (NamespaceFilter, error) {
+	include, err := parseNamespacePatterns(includePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include namespace patterns: %w", err)
+	}
+	exclude, err := parseNamespacePatterns(excludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude namespace patterns: %w", err)
+	}
+	labelFilter, err := NewLabelFilter(includeLabels, excludeLabels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace label rules: %w", err)
+	}
+	return namespaceFilterImpl{include: include, exclude: exclude, labelFilter: labelFilter}, nil
+}
+
+type namespaceFilterImpl struct {
+	include     []*regexp.Regexp
+	exclude     []*regexp.Regexp
+	labelFilter LabelFilter
+}
+
+func (f namespaceFilterImpl) Excluded(namespace string, namespaceLabels map[string]string) bool {
+	if len(f.include) > 0 && !matchesAnyPattern(f.include, namespace) {
+		return true
+	}
+	if matchesAnyPattern(f.exclude, namespace) {
+		return true
+	}
+	excluded, _ := f.labelFilter.Excluded(namespaceLabels)
+	return excluded
+}
+
+func matchesAnyPattern(patterns []*regexp.Regexp, value string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNamespacePatterns(patterns string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %w", pattern, err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceFilterExcluded(t *testing.T) {
+	filter, err := NewNamespaceFilter("team-.*,shared", "team-.*-tmp", "", "apihub/hidden=true")
+	assert.NoError(t, err)
+
+	assert.False(t, filter.Excluded("team-a", nil))
+	assert.False(t, filter.Excluded("shared", map[string]string{"apihub/hidden": "false"}))
+	assert.True(t, filter.Excluded("kube-system", nil))
+	assert.True(t, filter.Excluded("my-team-a", nil))
+	assert.True(t, filter.Excluded("team-a-tmp", nil))
+	assert.True(t, filter.Excluded("team-b", map[string]string{"apihub/hidden": "true"}))
+
+	_, err = NewNamespaceFilter("team-(", "", "", "")
+	assert.Error(t, err)
+}
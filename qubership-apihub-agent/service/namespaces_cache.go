@@ -4,6 +4,7 @@ import (
 	goctx "context"
 	"time"
 
+	"github.com/Netcracker/qubership-apihub-agent/view"
 	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/filter"
 	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/service"
 	"github.com/shaj13/libcache"
@@ -12,25 +13,27 @@ import (
 
 type NamespaceListCache interface {
 	ListNamespaces() ([]string, error)
+	ListNamespaceItems() ([]view.NamespaceItem, error)
 	GetCloudName() string
 	NamespaceExists(namespace string) (bool, error)
-	retrieveNamespaces() ([]string, error)
+	retrieveNamespaces() ([]view.NamespaceItem, error)
 }
 
-func NewNamespaceListCache(cloudName string, paasClient service.PlatformService, ttl time.Duration) NamespaceListCache {
+func NewNamespaceListCache(cloudName string, paasClient service.PlatformService, ttl time.Duration, namespaceFilter NamespaceFilter) NamespaceListCache {
 	cache := libcache.LRU.New(1)
 	cache.SetTTL(ttl)
 	cache.RegisterOnExpired(func(key, _ interface{}) {
 		cache.Delete(key)
 	})
-	return &namespaceListCacheImpl{cloudName: cloudName, cache: cache, paasClient: paasClient}
+	return &namespaceListCacheImpl{cloudName: cloudName, cache: cache, paasClient: paasClient, namespaceFilter: namespaceFilter}
 }
 
 type namespaceListCacheImpl struct {
 	cloudName string
 	cache     libcache.Cache
 
-	paasClient service.PlatformService
+	paasClient      service.PlatformService
+	namespaceFilter NamespaceFilter
 }
 
 const namespacesKey = "namespaces"
@@ -50,9 +53,21 @@ func (n *namespaceListCacheImpl) NamespaceExists(namespace string) (bool, error)
 }
 
 func (n *namespaceListCacheImpl) ListNamespaces() ([]string, error) {
+	items, err := n.ListNamespaceItems()
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, item := range items {
+		result = append(result, item.Name)
+	}
+	return result, nil
+}
+
+func (n *namespaceListCacheImpl) ListNamespaceItems() ([]view.NamespaceItem, error) {
 	val, exists := n.cache.Peek(namespacesKey)
 	if exists {
-		return val.([]string), nil
+		return val.([]view.NamespaceItem), nil
 	}
 
 	namespaces, err := n.retrieveNamespaces()
@@ -67,15 +82,23 @@ func (n *namespaceListCacheImpl) GetCloudName() string {
 	return n.cloudName
 }
 
-func (n *namespaceListCacheImpl) retrieveNamespaces() ([]string, error) {
-	var result []string
+func (n *namespaceListCacheImpl) retrieveNamespaces() ([]view.NamespaceItem, error) {
+	var result []view.NamespaceItem
 	ctx := goctx.Background()
 	nss, err := n.paasClient.GetNamespaces(ctx, filter.Meta{})
 	if err != nil {
 		return nil, err
 	}
 	for _, ns := range nss {
-		result = append(result, ns.GetMetadata().Name) // TODO: not sure about ns.GetMetadata().Name!!!
+		metadata := ns.GetMetadata() // TODO: not sure about ns.GetMetadata().Name!!!
+		if n.namespaceFilter.Excluded(metadata.Name, metadata.Labels) {
+			continue
+		}
+		result = append(result, view.NamespaceItem{
+			Name:        metadata.Name,
+			Labels:      metadata.Labels,
+			Annotations: metadata.Annotations,
+		})
 	}
 	return result, nil
 }
@@ -4,6 +4,7 @@ import (
 	goctx "context"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/Netcracker/qubership-apihub-agent/exception"
 	"github.com/Netcracker/qubership-apihub-agent/utils"
@@ -11,6 +12,8 @@ import (
 	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/entity"
 	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/filter"
 	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/service"
+	"github.com/shaj13/libcache"
+	_ "github.com/shaj13/libcache/lru"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,37 +22,53 @@ import (
 type ListService interface {
 	ListServiceNames(namespace string) ([]view.ServiceNameItem, error)
 	ListServiceItems(namespace string) ([]view.ServiceItem, error)
+	CountServices(ctx goctx.Context, namespaces []string) map[string]int
 }
 
+// namespaces are counted in parallel, but not all at once to avoid k8s API throttling
+const maxParallelServiceCounts = 10
+
+// NewListService makes the service which lists k8s services. Services count of the namespace is cached for serviceCountTtl, the same time as the namespaces list.
 func NewListService(cloudName string,
 	agentNamespace string,
-	excludeWithLabels []string,
+	labelFilter LabelFilter,
 	groupingLabels []string,
-	paasClient service.PlatformService) ListService {
+	paasClient service.PlatformService,
+	serviceCountTtl time.Duration) ListService {
 	groupingLabelsMap := make(map[string]struct{}, len(groupingLabels))
 	for _, label := range groupingLabels {
 		groupingLabelsMap[label] = struct{}{}
 	}
+	serviceCounts := libcache.LRU.New(0)
+	serviceCounts.SetTTL(serviceCountTtl)
+	serviceCounts.RegisterOnExpired(func(key, _ interface{}) {
+		serviceCounts.Delete(key)
+	})
 	return listServiceImpl{
-		cloudName:         cloudName,
-		agentNamespace:    agentNamespace,
-		excludeWithLabels: excludeWithLabels,
-		groupingLabels:    groupingLabelsMap,
-		paasClient:        paasClient,
+		cloudName:      cloudName,
+		agentNamespace: agentNamespace,
+		labelFilter:    labelFilter,
+		groupingLabels: groupingLabelsMap,
+		paasClient:     paasClient,
+		serviceCounts:  serviceCounts,
 	}
 }
 
 type listServiceImpl struct {
-	cloudName         string
-	agentNamespace    string
-	excludeWithLabels []string
-	groupingLabels    map[string]struct{}
-	paasClient        service.PlatformService
+	cloudName      string
+	agentNamespace string
+	labelFilter    LabelFilter
+	groupingLabels map[string]struct{}
+	paasClient     service.PlatformService
+	serviceCounts  libcache.Cache
 }
 
 func (l listServiceImpl) ListServiceNames(namespace string) ([]view.ServiceNameItem, error) {
+	return l.listServiceNames(goctx.Background(), namespace)
+}
+
+func (l listServiceImpl) listServiceNames(ctx goctx.Context, namespace string) ([]view.ServiceNameItem, error) {
 	var result []view.ServiceNameItem
-	ctx := goctx.Background()
 	list, err := l.paasClient.GetServiceList(ctx, namespace, filter.Meta{})
 	if err != nil {
 		switch paasErr := err.(type) {
@@ -73,7 +92,15 @@ func (l listServiceImpl) ListServiceNames(namespace string) ([]view.ServiceNameI
 	if list == nil {
 		return make([]view.ServiceNameItem, 0), nil
 	}
+	pods, err := l.paasClient.GetPodList(ctx, namespace, filter.Meta{})
+	if err != nil {
+		log.Errorf("Failed to list k8s pods in namespace %s: %s", namespace, err.Error())
+		return nil, err
+	}
 	for _, svc := range list {
+		if l.isExcluded(svc, pods) {
+			continue
+		}
 		annotations := getAllAnnotationsForService(svc)
 		result = append(result, view.ServiceNameItem{
 			Id:   svc.Name,
@@ -119,6 +146,7 @@ func (l listServiceImpl) ListServiceItems(namespace string) ([]view.ServiceItem,
 	}
 
 	agentId := utils.MakeAgentId(l.cloudName, l.agentNamespace)
+	resolveExternalHosts(ctx, services)
 
 	for _, srv := range services {
 		log.Infof("Getting pods for service: %s", srv.Name)
@@ -131,16 +159,9 @@ func (l listServiceImpl) ListServiceItems(namespace string) ([]view.ServiceItem,
 		for _, servicePod := range servicePods {
 			servicePodNames = append(servicePodNames, servicePod.Name)
 		}
-		// apply skip list for full list of labels
-		exclude := false
-		for _, label := range l.excludeWithLabels {
-			if _, ok := labels[label]; ok {
-				log.Infof("Service %s is excluded from discovery", srv.Name)
-				exclude = true
-				break
-			}
-		}
-		if exclude {
+		// apply label rules for full list of labels
+		if excluded, rule := l.labelFilter.Excluded(labels); excluded {
+			log.Infof("Service %s is excluded from discovery by label rule '%s'", srv.Name, rule)
 			continue
 		}
 		serviceId := srv.Name
@@ -167,6 +188,7 @@ func (l listServiceImpl) ListServiceItems(namespace string) ([]view.ServiceItem,
 			Annotations:    annotations,
 			Pods:           servicePodNames,
 			ProxyServerUrl: utils.MakeCustomProxyPath(agentId, namespace, serviceId),
+			External:       isExternalService(srv),
 		}
 
 		result = append(result, serviceItem)
@@ -174,3 +196,47 @@ func (l listServiceImpl) ListServiceItems(namespace string) ([]view.ServiceItem,
 
 	return result, nil
 }
+
+func (l listServiceImpl) isExcluded(srv entity.Service, pods []entity.Pod) bool {
+	labels := getAllLabelsForService(srv, getPodsForSelector(pods, srv.Spec.Selector))
+	excluded, _ := l.labelFilter.Excluded(labels)
+	return excluded
+}
+
+// CountServices returns number of not excluded services per namespace. Namespaces which failed to be listed are skipped.
+// Counts are cached, so only the namespaces missing in the cache are listed. Counting stops when ctx is cancelled, e.g. the client disconnected.
+func (l listServiceImpl) CountServices(ctx goctx.Context, namespaces []string) map[string]int {
+	result := make(map[string]int, len(namespaces))
+	mutex := sync.Mutex{}
+	limiter := utils.NewConcurrencyLimiter(maxParallelServiceCounts, 0)
+	wg := sync.WaitGroup{}
+	for _, namespace := range namespaces {
+		if count, exists := l.serviceCounts.Peek(namespace); exists {
+			result[namespace] = count.(int)
+			continue
+		}
+		ns := namespace
+		wg.Add(1)
+		utils.SafeAsync(func() {
+			defer wg.Done()
+			release, err := limiter.Acquire(ctx, ns)
+			if err != nil {
+				return
+			}
+			defer release()
+			names, err := l.listServiceNames(ctx, ns)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Errorf("Failed to count services in namespace %s: %s", ns, err.Error())
+				}
+				return
+			}
+			l.serviceCounts.Store(ns, len(names))
+			mutex.Lock()
+			result[ns] = len(names)
+			mutex.Unlock()
+		})
+	}
+	wg.Wait()
+	return result
+}
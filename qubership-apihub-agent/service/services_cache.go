@@ -14,30 +14,112 @@ import (
 
 type ServiceListCache interface {
 	GetServicesList(namespace string, workspaceId string) ([]view.Service, view.StatusEnum, string)
-	handleDiscoveryStart(namespace string, workspaceId string)
-	addService(namespace string, workspaceId string, service view.Service)
-	setResultStatus(namespace string, workspaceId string, status view.StatusEnum, details string)
+	// GetRemovedServices returns services found by the previous complete discovery only. Set when the discovery is complete.
+	GetRemovedServices(namespace string, workspaceId string) []view.Service
+	// GetPreviousServices returns result of the complete discovery preceding the current one along with its job id. Nil if there's no such result.
+	GetPreviousServices(namespace string, workspaceId string) (map[string]view.Service, string)
+	handleDiscoveryStart(namespace string, workspaceId string, jobId string)
+	// addService adds the service found by the discovery job. Results of cancelled or superseded job are dropped.
+	addService(namespace string, workspaceId string, jobId string, service view.Service)
+	updateService(namespace string, workspaceId string, service view.Service)
+	removeService(namespace string, workspaceId string, serviceId string)
+	setResultStatus(namespace string, workspaceId string, jobId string, status view.StatusEnum, details string)
 	clearResultsForNamespace(namespace string, workspaceId string)
+	// requestCancel asks other Agent replica sharing the results to cancel the discovery it runs. Returns false if the discovery is not run by other replica.
+	requestCancel(namespace string, workspaceId string) bool
+	// onCancelRequested sets the handler of the cancel requests sent by other replicas for the discoveries run by this one
+	onCancelRequested(handler func(namespace string, workspaceId string, jobId string))
+	// SubscribeToDiscovery returns the channel of discovery events. Already discovered services are sent first.
+	// The channel is closed after the terminal status event or if the subscriber doesn't read events fast enough.
+	SubscribeToDiscovery(namespace string, workspaceId string) (<-chan view.DiscoveryEvent, func())
 }
 
+// max number of not consumed events per subscriber, slow subscriber is dropped when exceeded
+const discoveryEventsBufferSize = 256
+
 type serviceCacheEntry struct {
 	services []view.Service
 	status   view.StatusEnum
 	details  string
+	jobId    string // discovery job which fills the entry
+
+	// results of the previous complete discovery, used to detect document changes. Nil if there were no such results.
+	previousServices map[string]view.Service
+	previousJobId    string
+	removedServices  []view.Service
 }
 
 func NewServiceListCache(ttl time.Duration) ServiceListCache {
+	return newServiceListCache(ttl, nil)
+}
+
+// NewPersistentServiceListCache creates the cache which keeps discovery results in the file at storePath as well.
+// Not expired results are loaded from the file, discovery interrupted by Agent restart is marked as failed.
+func NewPersistentServiceListCache(ttl time.Duration, storePath string) (ServiceListCache, error) {
+	store, err := newBoltServiceListStore(storePath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := store.loadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load discovery results from %s: %w", storePath, err)
+	}
+	s := newServiceListCache(ttl, store)
+	now := time.Now()
+	for id, stored := range entries {
+		// zero expiration time means that the entry doesn't expire
+		var remainingTTL time.Duration
+		if !stored.ExpiresAt.IsZero() {
+			remainingTTL = stored.ExpiresAt.Sub(now)
+			if remainingTTL <= 0 {
+				if err := store.delete(id); err != nil {
+					log.Errorf("Failed to delete expired discovery result %s from store: %s", id, err)
+				}
+				continue
+			}
+		}
+		entry := &serviceCacheEntry{
+			services:         stored.Services,
+			status:           stored.Status,
+			details:          stored.Details,
+			jobId:            stored.JobId,
+			previousServices: stored.PreviousServices,
+			previousJobId:    stored.PreviousJobId,
+			removedServices:  stored.RemovedServices,
+		}
+		if entry.services == nil {
+			entry.services = []view.Service{}
+		}
+		if entry.status == view.StatusRunning {
+			entry.status = view.StatusError
+			entry.details = "Discovery was interrupted by Agent restart"
+		}
+		s.cache.StoreWithTTL(id, entry, remainingTTL)
+	}
+	log.Infof("Loaded %d discovery results from %s", s.cache.Len(), storePath)
+	return s, nil
+}
+
+func newServiceListCache(ttl time.Duration, store serviceListStore) *serviceListCacheImpl {
 	cache := libcache.LRU.New(1000)
 	cache.SetTTL(ttl)
 	cache.RegisterOnExpired(func(key, _ interface{}) {
 		cache.Delete(key)
+		if store != nil {
+			if err := store.delete(key.(string)); err != nil {
+				log.Errorf("Failed to delete expired discovery result %s from store: %s", key, err)
+			}
+		}
 	})
-	return &serviceListCacheImpl{cache: cache}
+	return &serviceListCacheImpl{cache: cache, store: store, subscribers: map[string]map[chan view.DiscoveryEvent]struct{}{}}
 }
 
 type serviceListCacheImpl struct {
 	cache      libcache.Cache
 	cacheMutex sync.Mutex
+	store      serviceListStore // nil if results are kept in memory only
+
+	subscribers map[string]map[chan view.DiscoveryEvent]struct{} // guarded by cacheMutex
 }
 
 func (s *serviceListCacheImpl) GetServicesList(namespace string, workspaceId string) ([]view.Service, view.StatusEnum, string) {
@@ -52,16 +134,59 @@ func (s *serviceListCacheImpl) GetServicesList(namespace string, workspaceId str
 	return entry.services, entry.status, entry.details
 }
 
-func (s *serviceListCacheImpl) handleDiscoveryStart(namespace string, workspaceId string) {
+func (s *serviceListCacheImpl) GetRemovedServices(namespace string, workspaceId string) []view.Service {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	val, exists := s.cache.Peek(id)
+	if !exists {
+		return nil
+	}
+	return val.(*serviceCacheEntry).removedServices
+}
+
+func (s *serviceListCacheImpl) GetPreviousServices(namespace string, workspaceId string) (map[string]view.Service, string) {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	val, exists := s.cache.Peek(id)
+	if !exists {
+		return nil, ""
+	}
+	entry := val.(*serviceCacheEntry)
+	return entry.previousServices, entry.previousJobId
+}
+
+func (s *serviceListCacheImpl) handleDiscoveryStart(namespace string, workspaceId string, jobId string) {
 	s.cacheMutex.Lock()
 	defer s.cacheMutex.Unlock()
 
 	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
 
+	var previousServices map[string]view.Service
+	var previousJobId string
+	if val, exists := s.cache.Peek(id); exists {
+		prevEntry := val.(*serviceCacheEntry)
+		if prevEntry.status == view.StatusComplete {
+			previousServices = make(map[string]view.Service, len(prevEntry.services))
+			for _, srv := range prevEntry.services {
+				previousServices[srv.Id] = srv
+			}
+			previousJobId = prevEntry.jobId
+		} else {
+			// failed or cancelled discovery has incomplete results, so changes are still detected against the last complete one
+			previousServices = prevEntry.previousServices
+			previousJobId = prevEntry.previousJobId
+		}
+	}
+
 	s.cache.Store(id, &serviceCacheEntry{
-		services: []view.Service{},
-		status:   view.StatusRunning,
+		services:         []view.Service{},
+		status:           view.StatusRunning,
+		jobId:            jobId,
+		previousServices: previousServices,
+		previousJobId:    previousJobId,
 	})
+	s.persist(id)
+	s.notifySubscribers(id, view.DiscoveryEvent{Type: view.DiscoveryEventStatus, Status: &view.DiscoveryStatusEvent{Status: view.StatusRunning}})
 }
 
 func (s *serviceListCacheImpl) clearResultsForNamespace(namespace string, workspaceId string) {
@@ -74,9 +199,49 @@ func (s *serviceListCacheImpl) clearResultsForNamespace(namespace string, worksp
 		services: []view.Service{},
 		status:   view.StatusNone,
 	})
+	s.unpersist(id)
+	s.notifySubscribers(id, view.DiscoveryEvent{Type: view.DiscoveryEventStatus, Status: &view.DiscoveryStatusEvent{Status: view.StatusNone}})
+	s.closeSubscribers(id)
+}
+
+func (s *serviceListCacheImpl) addService(namespace string, workspaceId string, jobId string, service view.Service) {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	val, exists := s.cache.Peek(id)
+	if !exists {
+		return
+	}
+
+	entry := val.(*serviceCacheEntry)
+	if !isRunningJobEntry(entry.status, entry.jobId, jobId) {
+		log.Debugf("Dropping service %s found by stale discovery job %s of namespace %s and workspaceId %s", service.Id, jobId, namespace, workspaceId)
+		return
+	}
+	if entry.previousServices != nil {
+		previousService, existed := entry.previousServices[service.Id]
+		if existed {
+			setDocumentChanges(&service, &previousService)
+		} else {
+			setDocumentChanges(&service, nil)
+		}
+	}
+	// services slice could be already returned to the readers, so it's copied instead of sorting in place
+	services := make([]view.Service, 0, len(entry.services)+1)
+	services = append(services, entry.services...)
+	services = append(services, service)
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	entry.services = services
+	s.notifySubscribers(id, view.DiscoveryEvent{Type: view.DiscoveryEventService, Service: &service})
 }
 
-func (s *serviceListCacheImpl) addService(namespace string, workspaceId string, service view.Service) {
+// updateService replaces the service with the same id in existing result or adds it if not found. Services slice is copied since it could be already returned to the readers.
+// Results of running discovery are not changed since the service is going to be added by the discovery itself.
+func (s *serviceListCacheImpl) updateService(namespace string, workspaceId string, service view.Service) {
 	s.cacheMutex.Lock()
 	defer s.cacheMutex.Unlock()
 
@@ -84,21 +249,59 @@ func (s *serviceListCacheImpl) addService(namespace string, workspaceId string,
 
 	val, exists := s.cache.Peek(id)
 	if !exists {
-		s.cache.Store(id, &serviceCacheEntry{
-			services: []view.Service{service},
-		})
+		log.Warnf("Trying to update service %s in missing cache entry for namespace %s and workspaceId %s", service.Id, namespace, workspaceId)
 		return
 	}
 
 	entry := val.(*serviceCacheEntry)
-	entry.services = append(entry.services, service)
+	if entry.status == view.StatusRunning {
+		return
+	}
+	var previousService *view.Service
+	services := make([]view.Service, 0, len(entry.services)+1)
+	for i, srv := range entry.services {
+		if srv.Id != service.Id {
+			services = append(services, srv)
+		} else {
+			previousService = &entry.services[i]
+		}
+	}
+	setDocumentChanges(&service, previousService)
+	services = append(services, service)
 
-	sort.Slice(entry.services, func(i, j int) bool {
-		return entry.services[i].Name < entry.services[j].Name
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
 	})
+	entry.services = services
+	entry.removedServices = withoutService(entry.removedServices, service.Id)
+	s.persist(id)
 }
 
-func (s *serviceListCacheImpl) setResultStatus(namespace string, workspaceId string, status view.StatusEnum, details string) {
+func (s *serviceListCacheImpl) removeService(namespace string, workspaceId string, serviceId string) {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	val, exists := s.cache.Peek(id)
+	if !exists {
+		return
+	}
+
+	entry := val.(*serviceCacheEntry)
+	services := make([]view.Service, 0, len(entry.services))
+	for _, srv := range entry.services {
+		if srv.Id != serviceId {
+			services = append(services, srv)
+		} else {
+			entry.removedServices = withRemovedService(entry.removedServices, srv)
+		}
+	}
+	entry.services = services
+	s.persist(id)
+}
+
+func (s *serviceListCacheImpl) setResultStatus(namespace string, workspaceId string, jobId string, status view.StatusEnum, details string) {
 	s.cacheMutex.Lock()
 	defer s.cacheMutex.Unlock()
 
@@ -111,12 +314,236 @@ func (s *serviceListCacheImpl) setResultStatus(namespace string, workspaceId str
 	}
 
 	entry := val.(*serviceCacheEntry)
-	if entry.status == view.StatusRunning {
+	if isRunningJobEntry(entry.status, entry.jobId, jobId) {
 		entry.status = status
 		entry.details = details
+		if status == view.StatusComplete {
+			entry.removedServices = getRemovedServices(entry.services, entry.previousServices)
+		}
+		s.persist(id)
+		s.notifySubscribers(id, view.DiscoveryEvent{Type: view.DiscoveryEventStatus, Status: &view.DiscoveryStatusEvent{Status: status, Debug: details}})
+		if status != view.StatusRunning {
+			s.closeSubscribers(id)
+		}
 	}
 }
 
+// requestCancel returns false since the results kept in memory are not shared with other replicas
+func (s *serviceListCacheImpl) requestCancel(namespace string, workspaceId string) bool {
+	return false
+}
+
+func (s *serviceListCacheImpl) onCancelRequested(handler func(namespace string, workspaceId string, jobId string)) {
+}
+
+func (s *serviceListCacheImpl) SubscribeToDiscovery(namespace string, workspaceId string) (<-chan view.DiscoveryEvent, func()) {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	services := []view.Service{}
+	status := view.StatusNone
+	details := ""
+	if val, exists := s.cache.Peek(id); exists {
+		entry := val.(*serviceCacheEntry)
+		services = entry.services
+		status = entry.status
+		details = entry.details
+	}
+
+	events := make(chan view.DiscoveryEvent, len(services)+discoveryEventsBufferSize)
+	for _, service := range services {
+		// services slice is sorted in place by addService, so the events must not point to it
+		srv := service
+		events <- view.DiscoveryEvent{Type: view.DiscoveryEventService, Service: &srv}
+	}
+	events <- view.DiscoveryEvent{Type: view.DiscoveryEventStatus, Status: &view.DiscoveryStatusEvent{Status: status, Debug: details}}
+	if status != view.StatusRunning {
+		close(events)
+		return events, func() {}
+	}
+
+	if s.subscribers[id] == nil {
+		s.subscribers[id] = map[chan view.DiscoveryEvent]struct{}{}
+	}
+	s.subscribers[id][events] = struct{}{}
+
+	unsubscribe := func() {
+		s.cacheMutex.Lock()
+		defer s.cacheMutex.Unlock()
+		if _, exists := s.subscribers[id][events]; exists {
+			delete(s.subscribers[id], events)
+			close(events)
+		}
+		if len(s.subscribers[id]) == 0 {
+			delete(s.subscribers, id)
+		}
+	}
+	return events, unsubscribe
+}
+
+// persist saves the entry to the store if it's enabled. Services added by running discovery are not saved one by one,
+// the result is saved when the discovery is finished. Must be called under cacheMutex.
+func (s *serviceListCacheImpl) persist(id string) {
+	if s.store == nil {
+		return
+	}
+	val, exists := s.cache.Peek(id)
+	if !exists {
+		return
+	}
+	entry := val.(*serviceCacheEntry)
+	expiresAt, _ := s.cache.Expiry(id)
+	err := s.store.save(id, storedServiceListEntry{
+		Services:         entry.services,
+		Status:           entry.status,
+		Details:          entry.details,
+		JobId:            entry.jobId,
+		PreviousServices: entry.previousServices,
+		PreviousJobId:    entry.previousJobId,
+		RemovedServices:  entry.removedServices,
+		ExpiresAt:        expiresAt,
+	})
+	if err != nil {
+		log.Errorf("Failed to save discovery result %s to store: %s", id, err)
+	}
+}
+
+// unpersist must be called under cacheMutex
+func (s *serviceListCacheImpl) unpersist(id string) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.delete(id); err != nil {
+		log.Errorf("Failed to delete discovery result %s from store: %s", id, err)
+	}
+}
+
+// notifySubscribers must be called under cacheMutex
+func (s *serviceListCacheImpl) notifySubscribers(id string, event view.DiscoveryEvent) {
+	for events := range s.subscribers[id] {
+		select {
+		case events <- event:
+		default:
+			log.Warnf("Discovery events subscriber for %s is too slow, dropping it", id)
+			delete(s.subscribers[id], events)
+			close(events)
+		}
+	}
+}
+
+// closeSubscribers must be called under cacheMutex
+func (s *serviceListCacheImpl) closeSubscribers(id string) {
+	for events := range s.subscribers[id] {
+		close(events)
+	}
+	delete(s.subscribers, id)
+}
+
+// setDocumentChanges sets change status of the service documents relative to the previous discovery result of the service.
+// Documents are matched by port and path, documents missing in the new result are listed as removed.
+func setDocumentChanges(service *view.Service, previousService *view.Service) {
+	if service.NotReady {
+		// documents of not ready service are unknown
+		return
+	}
+	previousDocs := map[string]view.Document{}
+	if previousService != nil {
+		for _, doc := range previousService.Documents {
+			previousDocs[makeDocumentKey(doc.Port, doc.DocPath)] = doc
+		}
+	}
+	// documents could be shared with the caller
+	documents := make([]view.Document, len(service.Documents))
+	for i, doc := range service.Documents {
+		previousDoc, existed := previousDocs[makeDocumentKey(doc.Port, doc.DocPath)]
+		switch {
+		case !existed:
+			doc.ChangeStatus = view.DocumentNew
+		case doc.Hash != previousDoc.Hash:
+			doc.ChangeStatus = view.DocumentChanged
+		default:
+			doc.ChangeStatus = view.DocumentUnchanged
+		}
+		delete(previousDocs, makeDocumentKey(doc.Port, doc.DocPath))
+		documents[i] = doc
+	}
+	service.Documents = documents
+
+	service.RemovedDocuments = nil
+	if previousService != nil {
+		for _, doc := range previousService.Documents {
+			if _, removed := previousDocs[makeDocumentKey(doc.Port, doc.DocPath)]; removed {
+				doc.ChangeStatus = view.DocumentRemoved
+				service.RemovedDocuments = append(service.RemovedDocuments, doc)
+			}
+		}
+	}
+}
+
+// makeDocumentKey identifies the document within the service. The same path could be served with different content on several ports.
+func makeDocumentKey(port int32, docPath string) string {
+	return fmt.Sprintf("%d|%s", port, docPath)
+}
+
+// getRemovedServices returns services of the previous discovery missing in the current result. All their documents are listed as removed.
+func getRemovedServices(services []view.Service, previousServices map[string]view.Service) []view.Service {
+	found := make(map[string]struct{}, len(services))
+	for _, srv := range services {
+		found[srv.Id] = struct{}{}
+	}
+	var removedServices []view.Service
+	for id, previousService := range previousServices {
+		if _, exists := found[id]; exists {
+			continue
+		}
+		removedServices = append(removedServices, makeRemovedService(previousService))
+	}
+	sort.Slice(removedServices, func(i, j int) bool {
+		return removedServices[i].Name < removedServices[j].Name
+	})
+	return removedServices
+}
+
+// withRemovedService returns copy of the removed services with the service dropped from the result
+func withRemovedService(removedServices []view.Service, service view.Service) []view.Service {
+	result := append(withoutService(removedServices, service.Id), makeRemovedService(service))
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// makeRemovedService returns the service without documents, all its documents are listed as removed
+func makeRemovedService(service view.Service) view.Service {
+	removedService := view.Service{
+		Id:        service.Id,
+		Name:      service.Name,
+		Url:       service.Url,
+		Labels:    service.Labels,
+		Documents: []view.Document{},
+	}
+	setDocumentChanges(&removedService, &service)
+	return removedService
+}
+
+// withoutService returns copy of the services without the one with serviceId
+func withoutService(services []view.Service, serviceId string) []view.Service {
+	var result []view.Service
+	for _, srv := range services {
+		if srv.Id != serviceId {
+			result = append(result, srv)
+		}
+	}
+	return result
+}
+
+// isRunningJobEntry returns true if the entry is being filled by the job
+func isRunningJobEntry(status view.StatusEnum, entryJobId string, jobId string) bool {
+	return status == view.StatusRunning && entryJobId == jobId
+}
+
 const sep = "@||@"
 
 func getNamespaceWithWorkspaceId(namespace string, workspaceId string) string {
@@ -0,0 +1,738 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/utils"
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// max number of attempts to apply the change concurrently modified by other replicas
+const redisUpdateAttempts = 10
+
+// replica running the discovery refreshes the heartbeat of its entry. Running discovery without heartbeat for redisHeartbeatTimeout
+// is treated as interrupted, e.g. the replica was killed.
+const redisHeartbeatInterval = 10 * time.Second
+const redisHeartbeatTimeout = 3 * redisHeartbeatInterval
+
+// fields of the entry hash. Each service is kept in its own field, so services found concurrently don't conflict with each other.
+const redisStatusField = "status"
+const redisDetailsField = "details"
+const redisJobIdField = "jobId"
+const redisPreviousJobIdField = "previousJobId"
+const redisHasPreviousField = "hasPrevious"
+const redisExpiresAtField = "expiresAt"
+const redisOwnerField = "owner"
+const redisHeartbeatField = "heartbeat"
+const redisServiceFieldPrefix = "service:"
+const redisPreviousServiceFieldPrefix = "previous:"
+const redisRemovedServiceFieldPrefix = "removed:"
+
+// addServiceScript sets the service field and publishes the event if the entry is being filled by the job
+var addServiceScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' or redis.call('HGET', KEYS[1], 'jobId') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[3])
+redis.call('PUBLISH', ARGV[4], ARGV[5])
+return 1
+`)
+
+// heartbeatScript refreshes the heartbeat if the entry is still being filled by the job
+var heartbeatScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' or redis.call('HGET', KEYS[1], 'jobId') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'heartbeat', ARGV[2])
+return 1
+`)
+
+// NewRedisServiceListCache creates the cache which keeps discovery results in Redis, so the results are shared between Agent replicas.
+// Keys are prefixed by keyPrefix to separate Agent instances using the same Redis. replicaId must be unique for each replica.
+func NewRedisServiceListCache(ttl time.Duration, redisUrl string, keyPrefix string, replicaId string) (ServiceListCache, error) {
+	client, err := connectRedis(redisUrl)
+	if err != nil {
+		return nil, err
+	}
+	cache := newRedisServiceListCache(ttl, client, keyPrefix, replicaId)
+	if err := cache.start(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return cache, nil
+}
+
+func connectRedis(redisUrl string) (redis.UniversalClient, error) {
+	opts, err := redis.ParseURL(redisUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis url: %w", err)
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis %s: %w", opts.Addr, err)
+	}
+	return client, nil
+}
+
+func newRedisServiceListCache(ttl time.Duration, client redis.UniversalClient, keyPrefix string, replicaId string) *redisServiceListCacheImpl {
+	return &redisServiceListCacheImpl{
+		client:    client,
+		ttl:       ttl,
+		keyPrefix: keyPrefix,
+		replicaId: replicaId,
+		ownedJobs: map[string]string{},
+		stop:      func() {},
+	}
+}
+
+// redisServiceListCacheImpl keeps each namespace and workspace result in a single hash. Whole entry changes are applied in optimistic
+// transactions, services found by discovery are added by the script. Changes are published to the events channel of the entry.
+type redisServiceListCacheImpl struct {
+	client    redis.UniversalClient
+	ttl       time.Duration
+	keyPrefix string
+	replicaId string
+
+	ownedJobs     map[string]string // discovery jobs run by this replica by entry id, guarded by mutex
+	cancelHandler func(namespace string, workspaceId string, jobId string)
+	mutex         sync.Mutex
+	stop          context.CancelFunc
+}
+
+// redisServiceListEntry is the stored entry along with the replica which fills it
+type redisServiceListEntry struct {
+	storedServiceListEntry
+	owner     string
+	heartbeat time.Time
+}
+
+// markInterrupted reports running discovery as failed if its replica stopped refreshing the heartbeat. Returns true if the entry is marked.
+func (e *redisServiceListEntry) markInterrupted() bool {
+	if e.Status != view.StatusRunning || e.heartbeat.IsZero() || time.Since(e.heartbeat) < redisHeartbeatTimeout {
+		return false
+	}
+	e.Status = view.StatusError
+	e.Details = fmt.Sprintf("Discovery was interrupted: Agent replica %s stopped", e.owner)
+	return true
+}
+
+type discoveryCancelRequest struct {
+	Namespace   string `json:"namespace"`
+	WorkspaceId string `json:"workspaceId"`
+	JobId       string `json:"jobId"`
+}
+
+// start runs heartbeats of the discoveries run by this replica and handles cancel requests of other replicas
+func (r *redisServiceListCacheImpl) start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	pubsub := r.client.Subscribe(ctx, r.cancelChannel())
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		cancel()
+		return fmt.Errorf("failed to subscribe to discovery cancel requests: %w", err)
+	}
+	r.stop = cancel
+	utils.SafeAsync(func() {
+		r.sendHeartbeats(ctx)
+	})
+	utils.SafeAsync(func() {
+		r.handleCancelRequests(ctx, pubsub)
+	})
+	return nil
+}
+
+func (r *redisServiceListCacheImpl) GetServicesList(namespace string, workspaceId string) ([]view.Service, view.StatusEnum, string) {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	entry, err := r.getEntry(context.Background(), r.client, id)
+	if err != nil {
+		log.Errorf("Failed to read discovery result %s from redis: %s", id, err)
+		return make([]view.Service, 0), view.StatusError, fmt.Sprintf("Failed to read discovery result: %s", err)
+	}
+	if entry == nil {
+		return make([]view.Service, 0), view.StatusNone, ""
+	}
+	return entry.Services, entry.Status, entry.Details
+}
+
+func (r *redisServiceListCacheImpl) GetRemovedServices(namespace string, workspaceId string) []view.Service {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	entry, err := r.getEntry(context.Background(), r.client, id)
+	if err != nil {
+		log.Errorf("Failed to read discovery result %s from redis: %s", id, err)
+		return nil
+	}
+	if entry == nil {
+		return nil
+	}
+	return entry.RemovedServices
+}
+
+func (r *redisServiceListCacheImpl) GetPreviousServices(namespace string, workspaceId string) (map[string]view.Service, string) {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+
+	entry, err := r.getEntry(context.Background(), r.client, id)
+	if err != nil {
+		log.Errorf("Failed to read discovery result %s from redis: %s", id, err)
+		return nil, ""
+	}
+	if entry == nil {
+		return nil, ""
+	}
+	return entry.PreviousServices, entry.PreviousJobId
+}
+
+func (r *redisServiceListCacheImpl) handleDiscoveryStart(namespace string, workspaceId string, jobId string) {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+	r.update(id, func(entry *redisServiceListEntry) (*redisServiceListEntry, []view.DiscoveryEvent) {
+		var previousServices map[string]view.Service
+		var previousJobId string
+		if entry != nil {
+			if entry.Status == view.StatusComplete {
+				previousServices = make(map[string]view.Service, len(entry.Services))
+				for _, srv := range entry.Services {
+					previousServices[srv.Id] = srv
+				}
+				previousJobId = entry.JobId
+			} else {
+				// failed or cancelled discovery has incomplete results, so changes are still detected against the last complete one
+				previousServices = entry.PreviousServices
+				previousJobId = entry.PreviousJobId
+			}
+		}
+		newEntry := &redisServiceListEntry{
+			storedServiceListEntry: storedServiceListEntry{
+				Services:         []view.Service{},
+				Status:           view.StatusRunning,
+				JobId:            jobId,
+				PreviousServices: previousServices,
+				PreviousJobId:    previousJobId,
+				ExpiresAt:        r.newExpiresAt(),
+			},
+			owner:     r.replicaId,
+			heartbeat: time.Now(),
+		}
+		return newEntry, []view.DiscoveryEvent{{Type: view.DiscoveryEventStatus, Status: &view.DiscoveryStatusEvent{Status: view.StatusRunning}}}
+	})
+	r.mutex.Lock()
+	r.ownedJobs[id] = jobId
+	r.mutex.Unlock()
+}
+
+func (r *redisServiceListCacheImpl) clearResultsForNamespace(namespace string, workspaceId string) {
+	r.update(getNamespaceWithWorkspaceId(namespace, workspaceId), func(_ *redisServiceListEntry) (*redisServiceListEntry, []view.DiscoveryEvent) {
+		newEntry := &redisServiceListEntry{
+			storedServiceListEntry: storedServiceListEntry{
+				Services:  []view.Service{},
+				Status:    view.StatusNone,
+				ExpiresAt: r.newExpiresAt(),
+			},
+		}
+		return newEntry, []view.DiscoveryEvent{{Type: view.DiscoveryEventStatus, Status: &view.DiscoveryStatusEvent{Status: view.StatusNone}}}
+	})
+}
+
+// addService doesn't rewrite the entry, so services found concurrently by the same job are not lost. Previous result of the service
+// can't change while the job is running, so it's read before the service is added.
+func (r *redisServiceListCacheImpl) addService(namespace string, workspaceId string, jobId string, service view.Service) {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+	ctx := context.Background()
+	key := r.entryKey(id)
+
+	previous, err := r.client.HMGet(ctx, key, redisHasPreviousField, redisPreviousServiceFieldPrefix+service.Id).Result()
+	if err != nil {
+		log.Errorf("Failed to add service %s to discovery result %s in redis: %s", service.Id, id, err)
+		return
+	}
+	if previous[0] != nil {
+		var previousService *view.Service
+		if data, exists := previous[1].(string); exists {
+			previousService = &view.Service{}
+			if err := json.Unmarshal([]byte(data), previousService); err != nil {
+				log.Errorf("Failed to read previous result of service %s in %s: %s", service.Id, id, err)
+				return
+			}
+		}
+		setDocumentChanges(&service, previousService)
+	}
+
+	data, err := json.Marshal(service)
+	if err != nil {
+		log.Errorf("Failed to add service %s to discovery result %s in redis: %s", service.Id, id, err)
+		return
+	}
+	eventData, err := json.Marshal(view.DiscoveryEvent{Type: view.DiscoveryEventService, Service: &service})
+	if err != nil {
+		log.Errorf("Failed to add service %s to discovery result %s in redis: %s", service.Id, id, err)
+		return
+	}
+	added, err := addServiceScript.Run(ctx, r.client, []string{key}, jobId, redisServiceFieldPrefix+service.Id, data, r.eventsChannel(id), eventData).Int()
+	if err != nil {
+		log.Errorf("Failed to add service %s to discovery result %s in redis: %s", service.Id, id, err)
+		return
+	}
+	if added == 0 {
+		log.Debugf("Dropping service %s found by stale discovery job %s of namespace %s and workspaceId %s", service.Id, jobId, namespace, workspaceId)
+	}
+}
+
+func (r *redisServiceListCacheImpl) updateService(namespace string, workspaceId string, service view.Service) {
+	r.update(getNamespaceWithWorkspaceId(namespace, workspaceId), func(entry *redisServiceListEntry) (*redisServiceListEntry, []view.DiscoveryEvent) {
+		if entry == nil {
+			log.Warnf("Trying to update service %s in missing cache entry for namespace %s and workspaceId %s", service.Id, namespace, workspaceId)
+			return nil, nil
+		}
+		if entry.Status == view.StatusRunning {
+			return nil, nil
+		}
+		srv := service
+		var previousService *view.Service
+		services := make([]view.Service, 0, len(entry.Services)+1)
+		for i, existing := range entry.Services {
+			if existing.Id != srv.Id {
+				services = append(services, existing)
+			} else {
+				previousService = &entry.Services[i]
+			}
+		}
+		setDocumentChanges(&srv, previousService)
+		services = append(services, srv)
+		entry.Services = services
+		entry.RemovedServices = withoutService(entry.RemovedServices, srv.Id)
+		return entry, nil
+	})
+}
+
+func (r *redisServiceListCacheImpl) removeService(namespace string, workspaceId string, serviceId string) {
+	r.update(getNamespaceWithWorkspaceId(namespace, workspaceId), func(entry *redisServiceListEntry) (*redisServiceListEntry, []view.DiscoveryEvent) {
+		if entry == nil {
+			return nil, nil
+		}
+		for _, srv := range entry.Services {
+			if srv.Id == serviceId {
+				entry.RemovedServices = withRemovedService(entry.RemovedServices, srv)
+			}
+		}
+		entry.Services = withoutService(entry.Services, serviceId)
+		return entry, nil
+	})
+}
+
+func (r *redisServiceListCacheImpl) setResultStatus(namespace string, workspaceId string, jobId string, status view.StatusEnum, details string) {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+	r.update(id, func(entry *redisServiceListEntry) (*redisServiceListEntry, []view.DiscoveryEvent) {
+		if entry == nil {
+			log.Warnf("Trying to update missing entry cache status for namespace %s and workspaceId %s", namespace, workspaceId)
+			return nil, nil
+		}
+		if !isRunningJobEntry(entry.Status, entry.JobId, jobId) {
+			return nil, nil
+		}
+		entry.Status = status
+		entry.Details = details
+		if status == view.StatusComplete {
+			entry.RemovedServices = getRemovedServices(entry.Services, entry.PreviousServices)
+		}
+		return entry, []view.DiscoveryEvent{{Type: view.DiscoveryEventStatus, Status: &view.DiscoveryStatusEvent{Status: status, Debug: details}}}
+	})
+	if status != view.StatusRunning {
+		r.releaseJob(id, jobId)
+	}
+}
+
+// requestCancel publishes the cancel request to the replica which runs the discovery. Returns false if the discovery is not run by other replica.
+func (r *redisServiceListCacheImpl) requestCancel(namespace string, workspaceId string) bool {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+	ctx := context.Background()
+
+	entry, err := r.getEntryHeader(ctx, id)
+	if err != nil {
+		log.Errorf("Failed to read discovery result %s from redis: %s", id, err)
+		return false
+	}
+	if entry == nil || entry.markInterrupted() || entry.Status != view.StatusRunning || entry.owner == r.replicaId {
+		return false
+	}
+	data, err := json.Marshal(discoveryCancelRequest{Namespace: namespace, WorkspaceId: workspaceId, JobId: entry.JobId})
+	if err != nil {
+		log.Errorf("Failed to request cancel of discovery %s: %s", id, err)
+		return false
+	}
+	if err := r.client.Publish(ctx, r.cancelChannel(), data).Err(); err != nil {
+		log.Errorf("Failed to request cancel of discovery %s: %s", id, err)
+		return false
+	}
+	log.Infof("Requested Agent replica %s to cancel discovery job %s for namespace %s and workspaceId %s", entry.owner, entry.JobId, namespace, workspaceId)
+	return true
+}
+
+func (r *redisServiceListCacheImpl) onCancelRequested(handler func(namespace string, workspaceId string, jobId string)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cancelHandler = handler
+}
+
+// SubscribeToDiscovery subscribes to the events published by all replicas. Subscription is established before reading
+// the current result, so the events are not lost. Services already sent from the current result are skipped.
+func (r *redisServiceListCacheImpl) SubscribeToDiscovery(namespace string, workspaceId string) (<-chan view.DiscoveryEvent, func()) {
+	id := getNamespaceWithWorkspaceId(namespace, workspaceId)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pubsub := r.client.Subscribe(ctx, r.eventsChannel(id))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		log.Errorf("Failed to subscribe to discovery events of %s: %s", id, err)
+	}
+
+	entry, err := r.getEntry(ctx, r.client, id)
+	if err != nil {
+		log.Errorf("Failed to read discovery result %s from redis: %s", id, err)
+		entry = &redisServiceListEntry{storedServiceListEntry: storedServiceListEntry{Status: view.StatusError, Details: fmt.Sprintf("Failed to read discovery result: %s", err)}}
+	}
+	if entry == nil {
+		entry = &redisServiceListEntry{storedServiceListEntry: storedServiceListEntry{Status: view.StatusNone}}
+	}
+
+	events := make(chan view.DiscoveryEvent, len(entry.Services)+discoveryEventsBufferSize)
+	sentServices := make(map[string]struct{}, len(entry.Services))
+	for i := range entry.Services {
+		events <- view.DiscoveryEvent{Type: view.DiscoveryEventService, Service: &entry.Services[i]}
+		sentServices[entry.Services[i].Id] = struct{}{}
+	}
+	events <- view.DiscoveryEvent{Type: view.DiscoveryEventStatus, Status: &view.DiscoveryStatusEvent{Status: entry.Status, Debug: entry.Details}}
+	if entry.Status != view.StatusRunning {
+		pubsub.Close()
+		cancel()
+		close(events)
+		return events, func() {}
+	}
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		// replica running the discovery could stop without publishing the terminal status
+		heartbeatCheck := time.NewTicker(redisHeartbeatInterval)
+		defer heartbeatCheck.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeatCheck.C:
+				header, err := r.getEntryHeader(ctx, id)
+				if err != nil || header == nil || !header.markInterrupted() {
+					continue
+				}
+				select {
+				case events <- view.DiscoveryEvent{Type: view.DiscoveryEventStatus, Status: &view.DiscoveryStatusEvent{Status: header.Status, Debug: header.Details}}:
+				default:
+				}
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event view.DiscoveryEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					log.Errorf("Failed to read discovery event of %s: %s", id, err)
+					continue
+				}
+				if event.Type == view.DiscoveryEventService && event.Service != nil {
+					if _, sent := sentServices[event.Service.Id]; sent {
+						delete(sentServices, event.Service.Id)
+						continue
+					}
+				}
+				select {
+				case events <- event:
+				default:
+					log.Warnf("Discovery events subscriber for %s is too slow, dropping it", id)
+					return
+				}
+				if event.Type == view.DiscoveryEventStatus && event.Status != nil && event.Status.Status != view.StatusRunning {
+					return
+				}
+			}
+		}
+	}()
+	return events, cancel
+}
+
+// update applies the change to the current entry, nil entry is passed if there's no result. Change returns the entry to save
+// and events to publish, or nil if nothing should be saved. Change is applied again if the entry was concurrently modified.
+func (r *redisServiceListCacheImpl) update(id string, change func(entry *redisServiceListEntry) (*redisServiceListEntry, []view.DiscoveryEvent)) {
+	ctx := context.Background()
+	key := r.entryKey(id)
+	txf := func(tx *redis.Tx) error {
+		entry, err := r.getEntry(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		newEntry, events := change(entry)
+		if newEntry == nil {
+			return nil
+		}
+		fields, err := makeRedisEntryFields(newEntry)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			pipe.HSet(ctx, key, fields)
+			// zero expiration time means that the entry doesn't expire
+			if !newEntry.ExpiresAt.IsZero() {
+				pipe.PExpireAt(ctx, key, newEntry.ExpiresAt)
+			}
+			for _, event := range events {
+				eventData, err := json.Marshal(event)
+				if err != nil {
+					return err
+				}
+				pipe.Publish(ctx, r.eventsChannel(id), eventData)
+			}
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < redisUpdateAttempts; attempt++ {
+		err := r.client.Watch(ctx, txf, key)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			log.Errorf("Failed to update discovery result %s in redis: %s", id, err)
+			return
+		}
+	}
+	log.Errorf("Failed to update discovery result %s in redis: entry is concurrently modified", id)
+}
+
+// getEntry reads the whole entry. Discovery interrupted by stopped replica is reported as failed.
+func (r *redisServiceListCacheImpl) getEntry(ctx context.Context, cmd redis.Cmdable, id string) (*redisServiceListEntry, error) {
+	fields, err := cmd.HGetAll(ctx, r.entryKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	entry, err := parseRedisEntryFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	entry.markInterrupted()
+	return entry, nil
+}
+
+// getEntryHeader reads the entry without services
+func (r *redisServiceListCacheImpl) getEntryHeader(ctx context.Context, id string) (*redisServiceListEntry, error) {
+	names := []string{redisStatusField, redisDetailsField, redisJobIdField, redisOwnerField, redisHeartbeatField}
+	values, err := r.client.HMGet(ctx, r.entryKey(id), names...).Result()
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(names))
+	for i, name := range names {
+		if value, exists := values[i].(string); exists {
+			fields[name] = value
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return parseRedisEntryFields(fields)
+}
+
+func makeRedisEntryFields(entry *redisServiceListEntry) (map[string]interface{}, error) {
+	fields := map[string]interface{}{
+		redisStatusField:        string(entry.Status),
+		redisDetailsField:       entry.Details,
+		redisJobIdField:         entry.JobId,
+		redisPreviousJobIdField: entry.PreviousJobId,
+		redisOwnerField:         entry.owner,
+	}
+	if !entry.ExpiresAt.IsZero() {
+		fields[redisExpiresAtField] = entry.ExpiresAt.UnixMilli()
+	}
+	if !entry.heartbeat.IsZero() {
+		fields[redisHeartbeatField] = entry.heartbeat.UnixMilli()
+	}
+	if entry.PreviousServices != nil {
+		fields[redisHasPreviousField] = "true"
+	}
+	addServices := func(prefix string, services []view.Service) error {
+		for _, srv := range services {
+			data, err := json.Marshal(srv)
+			if err != nil {
+				return err
+			}
+			fields[prefix+srv.Id] = data
+		}
+		return nil
+	}
+	if err := addServices(redisServiceFieldPrefix, entry.Services); err != nil {
+		return nil, err
+	}
+	if err := addServices(redisPreviousServiceFieldPrefix, mapValues(entry.PreviousServices)); err != nil {
+		return nil, err
+	}
+	if err := addServices(redisRemovedServiceFieldPrefix, entry.RemovedServices); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func parseRedisEntryFields(fields map[string]string) (*redisServiceListEntry, error) {
+	entry := &redisServiceListEntry{
+		storedServiceListEntry: storedServiceListEntry{
+			Services:      []view.Service{},
+			Status:        view.StatusEnum(fields[redisStatusField]),
+			Details:       fields[redisDetailsField],
+			JobId:         fields[redisJobIdField],
+			PreviousJobId: fields[redisPreviousJobIdField],
+		},
+		owner: fields[redisOwnerField],
+	}
+	if expiresAt, err := strconv.ParseInt(fields[redisExpiresAtField], 10, 64); err == nil {
+		entry.ExpiresAt = time.UnixMilli(expiresAt)
+	}
+	if heartbeat, err := strconv.ParseInt(fields[redisHeartbeatField], 10, 64); err == nil {
+		entry.heartbeat = time.UnixMilli(heartbeat)
+	}
+	if fields[redisHasPreviousField] != "" {
+		entry.PreviousServices = map[string]view.Service{}
+	}
+	for name, value := range fields {
+		isService := strings.HasPrefix(name, redisServiceFieldPrefix)
+		isPreviousService := strings.HasPrefix(name, redisPreviousServiceFieldPrefix)
+		isRemovedService := strings.HasPrefix(name, redisRemovedServiceFieldPrefix)
+		if !isService && !isPreviousService && !isRemovedService {
+			continue
+		}
+		var srv view.Service
+		if err := json.Unmarshal([]byte(value), &srv); err != nil {
+			return nil, fmt.Errorf("failed to read field %s: %w", name, err)
+		}
+		switch {
+		case isService:
+			entry.Services = append(entry.Services, srv)
+		case isPreviousService:
+			if entry.PreviousServices == nil {
+				entry.PreviousServices = map[string]view.Service{}
+			}
+			entry.PreviousServices[srv.Id] = srv
+		default:
+			entry.RemovedServices = append(entry.RemovedServices, srv)
+		}
+	}
+	for _, services := range [][]view.Service{entry.Services, entry.RemovedServices} {
+		sort.Slice(services, func(i, j int) bool {
+			return services[i].Name < services[j].Name
+		})
+	}
+	return entry, nil
+}
+
+func mapValues(services map[string]view.Service) []view.Service {
+	result := make([]view.Service, 0, len(services))
+	for _, srv := range services {
+		result = append(result, srv)
+	}
+	return result
+}
+
+func (r *redisServiceListCacheImpl) sendHeartbeats(ctx context.Context) {
+	ticker := time.NewTicker(redisHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.mutex.Lock()
+		ownedJobs := maps.Clone(r.ownedJobs)
+		r.mutex.Unlock()
+		for id, jobId := range ownedJobs {
+			refreshed, err := heartbeatScript.Run(ctx, r.client, []string{r.entryKey(id)}, jobId, time.Now().UnixMilli()).Int()
+			if err != nil {
+				log.Errorf("Failed to refresh heartbeat of discovery %s in redis: %s", id, err)
+				continue
+			}
+			if refreshed == 0 {
+				// the job is finished or superseded by other replica
+				r.releaseJob(id, jobId)
+			}
+		}
+	}
+}
+
+func (r *redisServiceListCacheImpl) handleCancelRequests(ctx context.Context, pubsub *redis.PubSub) {
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var request discoveryCancelRequest
+			if err := json.Unmarshal([]byte(msg.Payload), &request); err != nil {
+				log.Errorf("Failed to read discovery cancel request: %s", err)
+				continue
+			}
+			r.mutex.Lock()
+			owned := r.ownedJobs[getNamespaceWithWorkspaceId(request.Namespace, request.WorkspaceId)] == request.JobId
+			handler := r.cancelHandler
+			r.mutex.Unlock()
+			if owned && handler != nil {
+				log.Infof("Cancelling discovery job %s for namespace %s and workspaceId %s requested by other Agent replica", request.JobId, request.Namespace, request.WorkspaceId)
+				handler(request.Namespace, request.WorkspaceId, request.JobId)
+			}
+		}
+	}
+}
+
+func (r *redisServiceListCacheImpl) releaseJob(id string, jobId string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.ownedJobs[id] == jobId {
+		delete(r.ownedJobs, id)
+	}
+}
+
+func (r *redisServiceListCacheImpl) newExpiresAt() time.Time {
+	if r.ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(r.ttl)
+}
+
+func (r *redisServiceListCacheImpl) entryKey(id string) string {
+	return r.keyPrefix + "services:" + id
+}
+
+func (r *redisServiceListCacheImpl) eventsChannel(id string) string {
+	return r.keyPrefix + "events:" + id
+}
+
+func (r *redisServiceListCacheImpl) cancelChannel() string {
+	return r.keyPrefix + "cancel"
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedisServiceListCaches(t *testing.T, ttl time.Duration) (*miniredis.Miniredis, ServiceListCache, ServiceListCache) {
+	mr := miniredis.RunT(t)
+	newReplica := func(replicaId string) ServiceListCache {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		cache := newRedisServiceListCache(ttl, client, "test:", replicaId)
+		assert.NoError(t, cache.start())
+		t.Cleanup(cache.stop)
+		return cache
+	}
+	return mr, newReplica("replicaA"), newReplica("replicaB")
+}
+
+func TestRedisServiceListCacheIsSharedBetweenReplicas(t *testing.T) {
+	_, replicaA, replicaB := newTestRedisServiceListCaches(t, time.Hour)
+
+	replicaA.handleDiscoveryStart("ns", "ws", "job")
+	replicaA.addService("ns", "ws", "job", view.Service{Id: "b", Name: "b", Documents: []view.Document{{DocPath: "/b", Hash: "1"}}})
+	replicaA.addService("ns", "ws", "job", view.Service{Id: "a", Name: "a"})
+
+	services, status, _ := replicaB.GetServicesList("ns", "ws")
+	assert.Equal(t, view.StatusRunning, status)
+	assert.Len(t, services, 2)
+	assert.Equal(t, "a", services[0].Id)
+
+	replicaA.setResultStatus("ns", "ws", "job", view.StatusComplete, "")
+	_, status, _ = replicaB.GetServicesList("ns", "ws")
+	assert.Equal(t, view.StatusComplete, status)
+
+	// next discovery flags changes against the previous result stored by the other replica
+	replicaB.handleDiscoveryStart("ns", "ws", "job2")
+	replicaB.addService("ns", "ws", "job2", view.Service{Id: "b", Name: "b", Documents: []view.Document{{DocPath: "/b", Hash: "2"}}})
+	services, _, _ = replicaA.GetServicesList("ns", "ws")
+	assert.Len(t, services, 1)
+	assert.Equal(t, view.DocumentChanged, services[0].Documents[0].ChangeStatus)
+
+	_, status, _ = replicaA.GetServicesList("other", "ws")
+	assert.Equal(t, view.StatusNone, status)
+}
+
+func TestRedisServiceListCacheReportsServiceRemovedAfterDiscovery(t *testing.T) {
+	_, replicaA, replicaB := newTestRedisServiceListCaches(t, time.Hour)
+	testRemoveServiceAfterDiscovery(t, replicaA, replicaB)
+}
+
+func TestRedisServiceListCacheExpires(t *testing.T) {
+	mr, replicaA, _ := newTestRedisServiceListCaches(t, time.Minute)
+
+	replicaA.handleDiscoveryStart("ns", "ws", "job")
+	replicaA.setResultStatus("ns", "ws", "job", view.StatusComplete, "")
+	mr.FastForward(2 * time.Minute)
+
+	_, status, _ := replicaA.GetServicesList("ns", "ws")
+	assert.Equal(t, view.StatusNone, status)
+}
+
+func TestRedisServiceListCacheStreamsEventsOfOtherReplica(t *testing.T) {
+	_, replicaA, replicaB := newTestRedisServiceListCaches(t, time.Hour)
+
+	replicaA.handleDiscoveryStart("ns", "ws", "job")
+	replicaA.addService("ns", "ws", "job", view.Service{Id: "a", Name: "a"})
+
+	events, unsubscribe := replicaB.SubscribeToDiscovery("ns", "ws")
+	defer unsubscribe()
+
+	replicaA.addService("ns", "ws", "job", view.Service{Id: "b", Name: "b"})
+	replicaA.setResultStatus("ns", "ws", "job", view.StatusComplete, "")
+
+	var received []view.DiscoveryEvent
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case event, opened := <-events:
+			if !opened {
+				done = true
+				break
+			}
+			received = append(received, event)
+		case <-timeout:
+			t.Fatal("discovery events stream is not closed")
+		}
+	}
+
+	if assert.Len(t, received, 4) {
+		assert.Equal(t, "a", received[0].Service.Id)
+		assert.Equal(t, view.StatusRunning, received[1].Status.Status)
+		assert.Equal(t, "b", received[2].Service.Id)
+		assert.Equal(t, view.StatusComplete, received[3].Status.Status)
+	}
+}
+
+func TestRedisServiceListCacheKeepsConcurrentlyAddedServices(t *testing.T) {
+	_, replicaA, replicaB := newTestRedisServiceListCaches(t, time.Hour)
+
+	replicaA.handleDiscoveryStart("ns", "ws", "job")
+	wg := sync.WaitGroup{}
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("srv-%03d", i)
+			replicaA.addService("ns", "ws", "job", view.Service{Id: id, Name: id})
+		}(i)
+	}
+	wg.Wait()
+	replicaA.setResultStatus("ns", "ws", "job", view.StatusComplete, "")
+
+	services, status, _ := replicaB.GetServicesList("ns", "ws")
+	assert.Equal(t, view.StatusComplete, status)
+	assert.Len(t, services, 200)
+	assert.Equal(t, "srv-000", services[0].Id)
+}
+
+func TestRedisServiceListCacheReportsDiscoveryOfStoppedReplica(t *testing.T) {
+	mr, replicaA, replicaB := newTestRedisServiceListCaches(t, time.Hour)
+
+	replicaA.handleDiscoveryStart("ns", "ws", "job")
+	replicaA.addService("ns", "ws", "job", view.Service{Id: "a", Name: "a"})
+	_, status, _ := replicaB.GetServicesList("ns", "ws")
+	assert.Equal(t, view.StatusRunning, status)
+
+	// replica A stopped refreshing the heartbeat
+	staleHeartbeat := time.Now().Add(-2 * redisHeartbeatTimeout).UnixMilli()
+	mr.HSet("test:services:"+getNamespaceWithWorkspaceId("ns", "ws"), redisHeartbeatField, strconv.FormatInt(staleHeartbeat, 10))
+
+	services, status, details := replicaB.GetServicesList("ns", "ws")
+	assert.Equal(t, view.StatusError, status)
+	assert.Contains(t, details, "replicaA")
+	assert.Len(t, services, 1)
+	assert.False(t, replicaB.requestCancel("ns", "ws"))
+}
+
+func TestRedisServiceListCacheSendsCancelToRunningReplica(t *testing.T) {
+	_, replicaA, replicaB := newTestRedisServiceListCaches(t, time.Hour)
+	cancelled := make(chan string, 1)
+	replicaA.onCancelRequested(func(namespace string, workspaceId string, jobId string) {
+		cancelled <- namespace + "/" + workspaceId + "/" + jobId
+	})
+
+	assert.False(t, replicaB.requestCancel("ns", "ws"))
+	replicaA.handleDiscoveryStart("ns", "ws", "job")
+	// replica running the discovery cancels it itself
+	assert.False(t, replicaA.requestCancel("ns", "ws"))
+	assert.True(t, replicaB.requestCancel("ns", "ws"))
+
+	select {
+	case request := <-cancelled:
+		assert.Equal(t, "ns/ws/job", request)
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancel request is not received")
+	}
+}
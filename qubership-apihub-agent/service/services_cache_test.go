@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceListCacheDropsStaleJobResults(t *testing.T) {
+	cache := NewServiceListCache(time.Hour)
+
+	cache.handleDiscoveryStart("ns", "ws", "job1")
+	cache.addService("ns", "ws", "job1", view.Service{Id: "a", Name: "a"})
+	cache.setResultStatus("ns", "ws", "job1", view.StatusCancelled, "discovery was cancelled")
+	// service of cancelled job finished after cancellation
+	cache.addService("ns", "ws", "job1", view.Service{Id: "b", Name: "b"})
+	services, status, _ := cache.GetServicesList("ns", "ws")
+	assert.Equal(t, view.StatusCancelled, status)
+	assert.Len(t, services, 1)
+
+	cache.handleDiscoveryStart("ns", "ws", "job2")
+	// superseded job doesn't affect the result of the next one
+	cache.addService("ns", "ws", "job1", view.Service{Id: "c", Name: "c"})
+	cache.setResultStatus("ns", "ws", "job1", view.StatusComplete, "")
+	cache.addService("ns", "ws", "job2", view.Service{Id: "d", Name: "d"})
+	services, status, _ = cache.GetServicesList("ns", "ws")
+	assert.Equal(t, view.StatusRunning, status)
+	assert.Len(t, services, 1)
+	assert.Equal(t, "d", services[0].Id)
+}
+
+func TestServiceListCacheReplayedEventsAreNotChangedByDiscovery(t *testing.T) {
+	cache := NewServiceListCache(time.Hour)
+	cache.handleDiscoveryStart("ns", "ws", "job")
+	cache.addService("ns", "ws", "job", view.Service{Id: "c", Name: "c"})
+	cache.addService("ns", "ws", "job", view.Service{Id: "b", Name: "b"})
+
+	events, unsubscribe := cache.SubscribeToDiscovery("ns", "ws")
+	defer unsubscribe()
+	replayed := []*view.Service{(<-events).Service, (<-events).Service}
+	assert.Equal(t, view.StatusRunning, (<-events).Status.Status)
+
+	cache.addService("ns", "ws", "job", view.Service{Id: "a", Name: "a"})
+	assert.Equal(t, "b", replayed[0].Id)
+	assert.Equal(t, "c", replayed[1].Id)
+	assert.Equal(t, "a", (<-events).Service.Id)
+}
+
+func TestServiceListCacheReportsRemovedServices(t *testing.T) {
+	cache := NewServiceListCache(time.Hour)
+	cache.handleDiscoveryStart("ns", "ws", "job1")
+	cache.addService("ns", "ws", "job1", view.Service{Id: "a", Name: "a", Documents: []view.Document{{DocPath: "/a", Hash: "1"}}})
+	cache.addService("ns", "ws", "job1", view.Service{Id: "b", Name: "b", Documents: []view.Document{{DocPath: "/b", Hash: "2"}}})
+	cache.setResultStatus("ns", "ws", "job1", view.StatusComplete, "")
+	assert.Empty(t, cache.GetRemovedServices("ns", "ws"))
+
+	cache.handleDiscoveryStart("ns", "ws", "job2")
+	cache.addService("ns", "ws", "job2", view.Service{Id: "a", Name: "a", Documents: []view.Document{{DocPath: "/a", Hash: "1"}}})
+	assert.Empty(t, cache.GetRemovedServices("ns", "ws"))
+	cache.setResultStatus("ns", "ws", "job2", view.StatusComplete, "")
+
+	removed := cache.GetRemovedServices("ns", "ws")
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "b", removed[0].Id)
+	assert.Empty(t, removed[0].Documents)
+	assert.Len(t, removed[0].RemovedDocuments, 1)
+	assert.Equal(t, view.DocumentRemoved, removed[0].RemovedDocuments[0].ChangeStatus)
+}
+
+func TestServiceListCacheReportsServiceRemovedAfterDiscovery(t *testing.T) {
+	cache := NewServiceListCache(time.Hour)
+	testRemoveServiceAfterDiscovery(t, cache, cache)
+}
+
+func testRemoveServiceAfterDiscovery(t *testing.T, cache ServiceListCache, otherCache ServiceListCache) {
+	cache.handleDiscoveryStart("ns", "ws", "job")
+	cache.addService("ns", "ws", "job", view.Service{Id: "a", Name: "a"})
+	cache.addService("ns", "ws", "job", view.Service{Id: "b", Name: "b", Documents: []view.Document{{DocPath: "/b", Hash: "1"}}})
+	cache.setResultStatus("ns", "ws", "job", view.StatusComplete, "")
+
+	cache.removeService("ns", "ws", "b")
+	services, _, _ := otherCache.GetServicesList("ns", "ws")
+	assert.Len(t, services, 1)
+	removed := otherCache.GetRemovedServices("ns", "ws")
+	if assert.Len(t, removed, 1) {
+		assert.Equal(t, "b", removed[0].Id)
+		assert.Empty(t, removed[0].Documents)
+		if assert.Len(t, removed[0].RemovedDocuments, 1) {
+			assert.Equal(t, "/b", removed[0].RemovedDocuments[0].DocPath)
+			assert.Equal(t, view.DocumentRemoved, removed[0].RemovedDocuments[0].ChangeStatus)
+		}
+	}
+
+	// service is back
+	cache.updateService("ns", "ws", view.Service{Id: "b", Name: "b"})
+	assert.Empty(t, otherCache.GetRemovedServices("ns", "ws"))
+}
+
+func TestServiceListCacheMatchesDocumentsByPort(t *testing.T) {
+	cache := NewServiceListCache(time.Hour)
+	documents := []view.Document{{DocPath: "/v3/api-docs", Port: 8080, Hash: "1"}, {DocPath: "/v3/api-docs", Port: 9090, Hash: "2"}}
+	cache.handleDiscoveryStart("ns", "ws", "job1")
+	cache.addService("ns", "ws", "job1", view.Service{Id: "a", Name: "a", Documents: documents})
+	cache.setResultStatus("ns", "ws", "job1", view.StatusComplete, "")
+
+	cache.handleDiscoveryStart("ns", "ws", "job2")
+	cache.addService("ns", "ws", "job2", view.Service{Id: "a", Name: "a", Documents: documents})
+	services, _, _ := cache.GetServicesList("ns", "ws")
+	assert.Len(t, services[0].Documents, 2)
+	for _, doc := range services[0].Documents {
+		assert.Equal(t, view.DocumentUnchanged, doc.ChangeStatus)
+	}
+}
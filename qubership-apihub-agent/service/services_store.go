@@ -0,0 +1,84 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// serviceListStore persists discovery results, so they survive Agent restart
+type serviceListStore interface {
+	save(id string, entry storedServiceListEntry) error
+	delete(id string) error
+	loadAll() (map[string]storedServiceListEntry, error)
+}
+
+type storedServiceListEntry struct {
+	Services         []view.Service          `json:"services"`
+	Status           view.StatusEnum         `json:"status"`
+	Details          string                  `json:"details,omitempty"`
+	JobId            string                  `json:"jobId,omitempty"`
+	PreviousServices map[string]view.Service `json:"previousServices,omitempty"`
+	PreviousJobId    string                  `json:"previousJobId,omitempty"`
+	RemovedServices  []view.Service          `json:"removedServices,omitempty"`
+	ExpiresAt        time.Time               `json:"expiresAt"`
+}
+
+var servicesBucket = []byte("services")
+
+// newBoltServiceListStore opens or creates single file key-value store
+func newBoltServiceListStore(path string) (serviceListStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open services store %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(servicesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init services store %s: %w", path, err)
+	}
+	return &boltServiceListStore{db: db}, nil
+}
+
+type boltServiceListStore struct {
+	db *bolt.DB
+}
+
+func (b *boltServiceListStore) save(id string, entry storedServiceListEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(servicesBucket).Put([]byte(id), data)
+	})
+}
+
+func (b *boltServiceListStore) delete(id string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(servicesBucket).Delete([]byte(id))
+	})
+}
+
+func (b *boltServiceListStore) loadAll() (map[string]storedServiceListEntry, error) {
+	entries := make(map[string]storedServiceListEntry)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(servicesBucket).ForEach(func(k, v []byte) error {
+			var entry storedServiceListEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				log.Errorf("Failed to read stored discovery result %s, skipping it: %s", string(k), err)
+				return nil
+			}
+			entries[string(k)] = entry
+			return nil
+		})
+	})
+	return entries, err
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistentServiceListCacheRestoresResults(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "services.db")
+	id := getNamespaceWithWorkspaceId("ns", "ws")
+	writeStoredEntries(t, storePath, map[string]storedServiceListEntry{
+		id: {
+			Services:         []view.Service{{Id: "a", Name: "a", Documents: []view.Document{{DocPath: "/a", Hash: "1"}}}},
+			Status:           view.StatusComplete,
+			JobId:            "job2",
+			PreviousServices: map[string]view.Service{"b": {Id: "b", Name: "b"}},
+			PreviousJobId:    "job1",
+			ExpiresAt:        time.Now().Add(time.Hour),
+		},
+		getNamespaceWithWorkspaceId("expired", "ws"): {
+			Services:  []view.Service{{Id: "a", Name: "a"}},
+			Status:    view.StatusComplete,
+			ExpiresAt: time.Now().Add(-time.Minute),
+		},
+		getNamespaceWithWorkspaceId("running", "ws"): {
+			Services:  []view.Service{{Id: "a", Name: "a"}},
+			Status:    view.StatusRunning,
+			JobId:     "job3",
+			ExpiresAt: time.Now().Add(time.Hour),
+		},
+	})
+
+	cache, err := NewPersistentServiceListCache(time.Hour, storePath)
+	assert.NoError(t, err)
+
+	services, status, _ := cache.GetServicesList("ns", "ws")
+	assert.Equal(t, view.StatusComplete, status)
+	assert.Len(t, services, 1)
+	assert.Equal(t, "1", services[0].Documents[0].Hash)
+	previousServices, previousJobId := cache.GetPreviousServices("ns", "ws")
+	assert.Contains(t, previousServices, "b")
+	assert.Equal(t, "job1", previousJobId)
+
+	services, status, _ = cache.GetServicesList("expired", "ws")
+	assert.Equal(t, view.StatusNone, status)
+	assert.Empty(t, services)
+
+	// discovery interrupted by restart can't be finished anymore
+	services, status, details := cache.GetServicesList("running", "ws")
+	assert.Equal(t, view.StatusError, status)
+	assert.NotEmpty(t, details)
+	assert.Len(t, services, 1)
+
+	stored, err := cache.(*serviceListCacheImpl).store.loadAll()
+	assert.NoError(t, err)
+	assert.NotContains(t, stored, getNamespaceWithWorkspaceId("expired", "ws"))
+}
+
+func TestPersistentServiceListCacheSavesResults(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "services.db")
+	cache, err := NewPersistentServiceListCache(time.Hour, storePath)
+	assert.NoError(t, err)
+	store := cache.(*serviceListCacheImpl).store
+	id := getNamespaceWithWorkspaceId("ns", "ws")
+
+	cache.handleDiscoveryStart("ns", "ws", "job")
+	cache.addService("ns", "ws", "job", view.Service{Id: "a", Name: "a"})
+	cache.setResultStatus("ns", "ws", "job", view.StatusComplete, "")
+	stored, err := store.loadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, view.StatusComplete, stored[id].Status)
+	assert.Len(t, stored[id].Services, 1)
+	assert.False(t, stored[id].ExpiresAt.IsZero())
+
+	cache.clearResultsForNamespace("ns", "ws")
+	stored, err = store.loadAll()
+	assert.NoError(t, err)
+	assert.NotContains(t, stored, id)
+}
+
+func writeStoredEntries(t *testing.T, storePath string, entries map[string]storedServiceListEntry) {
+	store, err := newBoltServiceListStore(storePath)
+	assert.NoError(t, err)
+	for id, entry := range entries {
+		assert.NoError(t, store.save(id, entry))
+	}
+	// the file is locked while it's open
+	assert.NoError(t, store.(*boltServiceListStore).db.Close())
+}
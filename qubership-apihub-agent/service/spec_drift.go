@@ -0,0 +1,170 @@
+package service
+
+import (
+	goctx "context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Netcracker/qubership-apihub-agent/utils"
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/entity"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/filter"
+	log "github.com/sirupsen/logrus"
+)
+
+// checkSpecDrift downloads the discovered documents from each ready pod of the service directly and compares their content.
+// Returns nil if all pods serve the same documents or if there are less than two ready pods to compare.
+// Documents which couldn't be downloaded from the pods are reported as inconclusive, so they are not mistaken for the ones without drift.
+func (d *discoveryServiceImpl) checkSpecDrift(ctx goctx.Context, srv entity.Service, documents []view.Document) *view.SpecDrift {
+	if len(srv.Spec.Selector) == 0 {
+		return nil
+	}
+	pods, err := d.paasClient.GetPodList(ctx, srv.Namespace, filter.Meta{Labels: srv.Spec.Selector})
+	if err != nil {
+		log.Errorf("Failed to list pods of service %s for spec drift check: %s", srv.Name, err)
+		return nil
+	}
+	readyPods := getReadyPods(getPodsForSelector(pods, srv.Spec.Selector))
+	if len(readyPods) < 2 {
+		return nil
+	}
+
+	drift := &view.SpecDrift{Documents: make([]view.DocumentDrift, 0)}
+	for _, pod := range readyPods {
+		drift.Pods = append(drift.Pods, pod.Name)
+	}
+	podDocuments := make([][]view.PodDocument, len(documents))
+	wg := sync.WaitGroup{}
+	for i, document := range documents {
+		podBaseUrls, reason := getPodBaseUrls(srv, document.Port, readyPods)
+		if reason != "" {
+			drift.Inconclusive = append(drift.Inconclusive, view.InconclusiveDocument{FileId: document.FileId, DocPath: document.DocPath, Reason: reason})
+			continue
+		}
+		podDocuments[i] = make([]view.PodDocument, len(readyPods))
+		for j, pod := range readyPods {
+			i, j, document, pod := i, j, document, pod
+			wg.Add(1)
+			utils.SafeAsync(func() {
+				defer wg.Done()
+				podDocument := view.PodDocument{PodName: pod.Name}
+				content, err := getDocumentContent(ctx, podBaseUrls[j]+document.DocPath, document.Type, document.Format, d.podDocumentTimeout)
+				if err != nil {
+					podDocument.Error = err.Error()
+				} else {
+					podDocument.Hash = utils.GetContentHash(content)
+				}
+				podDocuments[i][j] = podDocument
+			})
+		}
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	for i, document := range documents {
+		if podDocuments[i] == nil {
+			continue
+		}
+		if isDrifted(podDocuments[i]) {
+			drift.Documents = append(drift.Documents, view.DocumentDrift{FileId: document.FileId, DocPath: document.DocPath, Pods: podDocuments[i]})
+		} else if podDocuments[i][0].Hash == "" {
+			drift.Inconclusive = append(drift.Inconclusive, view.InconclusiveDocument{FileId: document.FileId, DocPath: document.DocPath,
+				Reason: fmt.Sprintf("none of the pods served the document: %s", podDocuments[i][0].Error)})
+		}
+	}
+	if len(drift.Documents) == 0 && len(drift.Inconclusive) == 0 {
+		return nil
+	}
+	if len(drift.Documents) > 0 {
+		log.Infof("Pods of service %s serve different content of %d document(s)", srv.Name, len(drift.Documents))
+	}
+	if len(drift.Inconclusive) > 0 {
+		log.Infof("Spec drift check of %d document(s) of service %s is inconclusive", len(drift.Inconclusive), srv.Name)
+	}
+	return drift
+}
+
+// getPodBaseUrls returns urls of the pods port the service port is routed to, in the order of pods.
+// Returns the reason if the document can't be requested from the pods.
+func getPodBaseUrls(srv entity.Service, portNumber int32, pods []entity.Pod) ([]string, string) {
+	port := findServicePort(srv, strconv.Itoa(int(portNumber)))
+	if port == nil {
+		return nil, fmt.Sprintf("port %d is not found in the service", portNumber)
+	}
+	if isTlsPort(srv, *port) && utils.IsDiscoveryTlsVerified() {
+		// pod certificates are issued for the service host name, not for the pod IP
+		return nil, fmt.Sprintf("certificate of port %d can't be verified for the pod IP", portNumber)
+	}
+	baseUrls := make([]string, len(pods))
+	for i, pod := range pods {
+		podPort := resolvePodPort(*port, pod)
+		if podPort == 0 {
+			return nil, fmt.Sprintf("target port of port %d is not found in the containers of pod %s", portNumber, pod.Name)
+		}
+		baseUrls[i] = buildPodBaseurl(srv, *port, podPort, pod)
+	}
+	return baseUrls, ""
+}
+
+// resolvePodPort returns the pod port the service port is routed to. Named target port is reported as 0 by the client without its name,
+// so the container port with the name of the service port is used, or the only TCP container port. Returns 0 if the port is not resolved.
+func resolvePodPort(port entity.Port, pod entity.Pod) int32 {
+	if port.TargetPort != 0 {
+		return port.TargetPort
+	}
+	var tcpPorts []int32
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			if port.Name != "" && containerPort.Name == port.Name {
+				return containerPort.ContainerPort
+			}
+			if containerPort.Protocol == "" || strings.EqualFold(containerPort.Protocol, "TCP") {
+				tcpPorts = append(tcpPorts, containerPort.ContainerPort)
+			}
+		}
+	}
+	if len(tcpPorts) == 1 {
+		return tcpPorts[0]
+	}
+	return 0
+}
+
+// isDrifted checks if pods served different content. Pod which failed to serve the document differs from the ones which served it.
+func isDrifted(podDocuments []view.PodDocument) bool {
+	for _, podDocument := range podDocuments[1:] {
+		if podDocument.Hash != podDocuments[0].Hash {
+			return true
+		}
+	}
+	return false
+}
+
+func getReadyPods(pods []entity.Pod) []entity.Pod {
+	readyPods := make([]entity.Pod, 0)
+	for _, pod := range pods {
+		if pod.Status.PodIP == "" {
+			continue
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if containerStatus.Ready {
+				readyPods = append(readyPods, pod)
+				break
+			}
+		}
+	}
+	return readyPods
+}
+
+// buildPodBaseurl returns url of the pod port the service port is routed to
+func buildPodBaseurl(srv entity.Service, port entity.Port, podPort int32, pod entity.Pod) string {
+	scheme := "http://"
+	if isTlsPort(srv, port) {
+		scheme = "https://"
+	}
+	return scheme + net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(podPort)))
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Netcracker/qubership-apihub-agent/view"
+	"github.com/netcracker/qubership-core-lib-go-paas-mediation-client/v8/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDrifted(t *testing.T) {
+	assert.False(t, isDrifted([]view.PodDocument{{PodName: "a", Hash: "1"}, {PodName: "b", Hash: "1"}}))
+	assert.True(t, isDrifted([]view.PodDocument{{PodName: "a", Hash: "1"}, {PodName: "b", Hash: "2"}}))
+	assert.True(t, isDrifted([]view.PodDocument{{PodName: "a", Hash: "1"}, {PodName: "b", Error: "not found"}}))
+}
+
+func TestBuildPodBaseurl(t *testing.T) {
+	srv := entity.Service{Metadata: entity.Metadata{Name: "svc", Namespace: "ns"}}
+	pod := entity.Pod{Status: entity.PodStatus{PodIP: "10.0.0.1"}}
+	assert.Equal(t, "http://10.0.0.1:8081", buildPodBaseurl(srv, entity.Port{Name: "web", Port: 8080, TargetPort: 8081}, 8081, pod))
+	assert.Equal(t, "https://10.0.0.1:8443", buildPodBaseurl(srv, entity.Port{Name: "https", Port: 8443}, 8443, pod))
+}
+
+func TestResolvePodPort(t *testing.T) {
+	pod := entity.Pod{Spec: entity.PodSpec{Containers: []entity.SpecContainer{
+		{Ports: []entity.ContainerPort{{Name: "web", ContainerPort: 8081, Protocol: "TCP"}, {Name: "metrics", ContainerPort: 9090, Protocol: "TCP"}}},
+	}}}
+	assert.Equal(t, int32(8082), resolvePodPort(entity.Port{Name: "web", Port: 80, TargetPort: 8082}, pod))
+	// named target port is reported as 0
+	assert.Equal(t, int32(8081), resolvePodPort(entity.Port{Name: "web", Port: 80}, pod))
+	assert.Equal(t, int32(0), resolvePodPort(entity.Port{Name: "http", Port: 80}, pod))
+
+	singlePortPod := entity.Pod{Spec: entity.PodSpec{Containers: []entity.SpecContainer{{Ports: []entity.ContainerPort{{Name: "api", ContainerPort: 8080}}}}}}
+	assert.Equal(t, int32(8080), resolvePodPort(entity.Port{Name: "http", Port: 80}, singlePortPod))
+}
+
+func TestGetPodBaseUrlsReportsUnresolvedPort(t *testing.T) {
+	srv := entity.Service{Metadata: entity.Metadata{Name: "svc", Namespace: "ns"}, Spec: entity.ServiceSpec{Ports: []entity.Port{{Name: "http", Port: 80}}}}
+	pods := []entity.Pod{{Metadata: entity.Metadata{Name: "a"}, Status: entity.PodStatus{PodIP: "10.0.0.1"}}}
+
+	urls, reason := getPodBaseUrls(srv, 80, pods)
+	assert.Nil(t, urls)
+	assert.NotEmpty(t, reason)
+
+	_, reason = getPodBaseUrls(srv, 8080, pods)
+	assert.NotEmpty(t, reason)
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
@@ -23,12 +24,36 @@ type SystemInfoService interface {
 	GetDiscoveryConfig() string
 	GetCloudName() string
 	GetAgentNamespace() string
-	GetExcludeLabels() []string
+	GetIncludeLabels() string
+	GetExcludeLabels() string
 	GetGroupingLabels() []string
+	GetIncludeNamespaces() string
+	GetExcludeNamespaces() string
+	GetIncludeNamespaceLabels() string
+	GetExcludeNamespaceLabels() string
 	GetAgentName() string
 	GetDiscoveryTimeout() time.Duration
 	GetNamespacesCacheTTL() time.Duration
 	GetServicesCacheTTL() time.Duration
+	GetDiscoveryMaxConcurrentRequests() int
+	GetDiscoveryMaxConcurrentRequestsPerService() int
+	GetDiscoveryMaxParallelNamespaces() int
+	GetDiscoverySchedules() []view.DiscoverySchedule
+	GetDiscoveryWatchEnabled() bool
+	GetDiscoveryMaxRetries() int
+	GetDiscoveryRetryBackoff() time.Duration
+	GetDiscoveryRetryMaxBackoff() time.Duration
+	GetDiscoveryReadinessTimeout() time.Duration
+	GetDiscoverySpecDriftCheckEnabled() bool
+	GetDiscoveryCaBundlePath() string
+	GetDiscoveryClientCertPath() string
+	GetDiscoveryClientKeyPath() string
+	GetDiscoveryTlsInsecure() bool
+	GetServicesCacheStorePath() string
+	GetServicesCacheRedisUrl() string
+	GetLeaderElectionEnabled() bool
+	GetLeaderElectionLockFile() string
+	GetPodIp() string
 }
 
 func NewSystemInfoService() (SystemInfoService, error) {
@@ -56,12 +81,45 @@ func NewSystemInfoService() (SystemInfoService, error) {
 		DiscoveryConfig:  getDiscoveryConfig(),
 		CloudName:        cloudName,
 		AgentNamespace:   agentNamespace,
-		ExcludeLabels:    getExcludeLabels(),
+		IncludeLabels:    os.Getenv("DISCOVERY_INCLUDE_LABELS"),
+		ExcludeLabels:    os.Getenv("DISCOVERY_EXCLUDE_LABELS"),
 		GroupingLabels:   getGroupingLabels(),
 		AgentName:        agentName,
 		DiscoveryTimeout:   getDiscoveryTimeout(),
 		NamespacesCacheTTL: getNamespacesCacheTTL(),
 		ServicesCacheTTL:   getServicesCacheTTL(),
+
+		DiscoveryMaxConcurrentRequests:           getDiscoveryMaxConcurrentRequests(),
+		DiscoveryMaxConcurrentRequestsPerService: getDiscoveryMaxConcurrentRequestsPerService(),
+		DiscoveryMaxParallelNamespaces:           getDiscoveryMaxParallelNamespaces(),
+
+		DiscoverySchedules:    getDiscoverySchedules(),
+		DiscoveryWatchEnabled: getDiscoveryWatchEnabled(),
+
+		DiscoveryMaxRetries:      getDiscoveryMaxRetries(),
+		DiscoveryRetryBackoff:    getDiscoveryRetryBackoff(),
+		DiscoveryRetryMaxBackoff: getDiscoveryRetryMaxBackoff(),
+
+		DiscoveryReadinessTimeout: getDiscoveryReadinessTimeout(),
+
+		DiscoverySpecDriftCheckEnabled: getDiscoverySpecDriftCheckEnabled(),
+
+		IncludeNamespaces:      os.Getenv("DISCOVERY_INCLUDE_NAMESPACES"),
+		ExcludeNamespaces:      os.Getenv("DISCOVERY_EXCLUDE_NAMESPACES"),
+		IncludeNamespaceLabels: os.Getenv("DISCOVERY_INCLUDE_NAMESPACE_LABELS"),
+		ExcludeNamespaceLabels: os.Getenv("DISCOVERY_EXCLUDE_NAMESPACE_LABELS"),
+
+		DiscoveryCaBundlePath:   os.Getenv("DISCOVERY_CA_BUNDLE_PATH"),
+		DiscoveryClientCertPath: os.Getenv("DISCOVERY_CLIENT_CERT_PATH"),
+		DiscoveryClientKeyPath:  os.Getenv("DISCOVERY_CLIENT_KEY_PATH"),
+		DiscoveryTlsInsecure:    getDiscoveryTlsInsecure(),
+
+		ServicesCacheStorePath: os.Getenv("SERVICES_CACHE_STORE_PATH"),
+		ServicesCacheRedisUrl:  os.Getenv("SERVICES_CACHE_REDIS_URL"),
+
+		LeaderElectionEnabled:  getLeaderElectionEnabled(),
+		LeaderElectionLockFile: os.Getenv("LEADER_ELECTION_LOCK_FILE"),
+		PodIp:                  os.Getenv("POD_IP"),
 	}
 	return &systemInfoServiceImpl{
 		systemInfo: systemInfo}, nil
@@ -103,7 +161,11 @@ func (g systemInfoServiceImpl) GetAgentNamespace() string {
 	return g.systemInfo.AgentNamespace
 }
 
-func (g systemInfoServiceImpl) GetExcludeLabels() []string {
+func (g systemInfoServiceImpl) GetIncludeLabels() string {
+	return g.systemInfo.IncludeLabels
+}
+
+func (g systemInfoServiceImpl) GetExcludeLabels() string {
 	return g.systemInfo.ExcludeLabels
 }
 
@@ -111,6 +173,22 @@ func (g systemInfoServiceImpl) GetGroupingLabels() []string {
 	return g.systemInfo.GroupingLabels
 }
 
+func (g systemInfoServiceImpl) GetIncludeNamespaces() string {
+	return g.systemInfo.IncludeNamespaces
+}
+
+func (g systemInfoServiceImpl) GetExcludeNamespaces() string {
+	return g.systemInfo.ExcludeNamespaces
+}
+
+func (g systemInfoServiceImpl) GetIncludeNamespaceLabels() string {
+	return g.systemInfo.IncludeNamespaceLabels
+}
+
+func (g systemInfoServiceImpl) GetExcludeNamespaceLabels() string {
+	return g.systemInfo.ExcludeNamespaceLabels
+}
+
 func (g systemInfoServiceImpl) GetAgentName() string {
 	return g.systemInfo.AgentName
 }
@@ -127,6 +205,82 @@ func (g systemInfoServiceImpl) GetServicesCacheTTL() time.Duration {
 	return g.systemInfo.ServicesCacheTTL
 }
 
+func (g systemInfoServiceImpl) GetDiscoveryMaxConcurrentRequests() int {
+	return g.systemInfo.DiscoveryMaxConcurrentRequests
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryMaxConcurrentRequestsPerService() int {
+	return g.systemInfo.DiscoveryMaxConcurrentRequestsPerService
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryMaxParallelNamespaces() int {
+	return g.systemInfo.DiscoveryMaxParallelNamespaces
+}
+
+func (g systemInfoServiceImpl) GetDiscoverySchedules() []view.DiscoverySchedule {
+	return g.systemInfo.DiscoverySchedules
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryWatchEnabled() bool {
+	return g.systemInfo.DiscoveryWatchEnabled
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryMaxRetries() int {
+	return g.systemInfo.DiscoveryMaxRetries
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryRetryBackoff() time.Duration {
+	return g.systemInfo.DiscoveryRetryBackoff
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryRetryMaxBackoff() time.Duration {
+	return g.systemInfo.DiscoveryRetryMaxBackoff
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryReadinessTimeout() time.Duration {
+	return g.systemInfo.DiscoveryReadinessTimeout
+}
+
+func (g systemInfoServiceImpl) GetDiscoverySpecDriftCheckEnabled() bool {
+	return g.systemInfo.DiscoverySpecDriftCheckEnabled
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryCaBundlePath() string {
+	return g.systemInfo.DiscoveryCaBundlePath
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryClientCertPath() string {
+	return g.systemInfo.DiscoveryClientCertPath
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryClientKeyPath() string {
+	return g.systemInfo.DiscoveryClientKeyPath
+}
+
+func (g systemInfoServiceImpl) GetDiscoveryTlsInsecure() bool {
+	return g.systemInfo.DiscoveryTlsInsecure
+}
+
+func (g systemInfoServiceImpl) GetServicesCacheStorePath() string {
+	return g.systemInfo.ServicesCacheStorePath
+}
+
+func (g systemInfoServiceImpl) GetServicesCacheRedisUrl() string {
+	return g.systemInfo.ServicesCacheRedisUrl
+}
+
+func (g systemInfoServiceImpl) GetLeaderElectionEnabled() bool {
+	return g.systemInfo.LeaderElectionEnabled
+}
+
+func (g systemInfoServiceImpl) GetLeaderElectionLockFile() string {
+	return g.systemInfo.LeaderElectionLockFile
+}
+
+func (g systemInfoServiceImpl) GetPodIp() string {
+	return g.systemInfo.PodIp
+}
+
 func getInsecureProxy() bool {
 	envVal := os.Getenv("INSECURE_PROXY")
 	if envVal == "" {
@@ -189,22 +343,6 @@ func getAgentNamespace() (string, error) {
 	return agentNamespace, nil
 }
 
-func getExcludeLabels() []string {
-	excludeLablesStr := os.Getenv("DISCOVERY_EXCLUDE_LABELS")
-	if excludeLablesStr == "" {
-		return []string{}
-	}
-	labels := strings.Split(excludeLablesStr, ",")
-	var cleanedExcludeLabels []string
-	for _, label := range labels {
-		cleanedLabel := strings.TrimSpace(label)
-		if cleanedLabel != "" {
-			cleanedExcludeLabels = append(cleanedExcludeLabels, cleanedLabel)
-		}
-	}
-	return cleanedExcludeLabels
-}
-
 func getGroupingLabels() []string {
 	groupingLablesStr := os.Getenv("DISCOVERY_GROUPING_LABELS")
 	if groupingLablesStr == "" {
@@ -270,6 +408,123 @@ func getServicesCacheTTL() time.Duration {
 	return time.Minute * time.Duration(ttlMin)
 }
 
+func getDiscoveryMaxConcurrentRequests() int {
+	return getPositiveIntEnv("DISCOVERY_MAX_CONCURRENT_REQUESTS", 100)
+}
+
+func getDiscoveryMaxConcurrentRequestsPerService() int {
+	return getPositiveIntEnv("DISCOVERY_MAX_CONCURRENT_REQUESTS_PER_SERVICE", 10)
+}
+
+func getDiscoveryMaxParallelNamespaces() int {
+	return getPositiveIntEnv("DISCOVERY_MAX_PARALLEL_NAMESPACES", 5)
+}
+
+// Example: [{"cron": "0 */4 * * *", "namespaces": ["ns1", "ns2"], "workspaceId": "QS"}]. Use "*" namespace to discover all namespaces.
+func getDiscoverySchedules() []view.DiscoverySchedule {
+	schedulesStr := os.Getenv("DISCOVERY_SCHEDULES")
+	if schedulesStr == "" {
+		return nil
+	}
+	var schedules []view.DiscoverySchedule
+	err := json.Unmarshal([]byte(schedulesStr), &schedules)
+	if err != nil {
+		log.Errorf("Failed to parse DISCOVERY_SCHEDULES value = '%s' with err = '%s', scheduled discovery is disabled", schedulesStr, err)
+		return nil
+	}
+	return schedules
+}
+
+func getDiscoveryWatchEnabled() bool {
+	envVal := os.Getenv("DISCOVERY_WATCH_ENABLED")
+	if envVal == "" {
+		return true
+	}
+	watchEnabled, err := strconv.ParseBool(envVal)
+	if err != nil {
+		log.Errorf("Failed to parse DISCOVERY_WATCH_ENABLED value = '%s' with err = '%s', using default = true", envVal, err)
+		return true
+	}
+	return watchEnabled
+}
+
+func getDiscoverySpecDriftCheckEnabled() bool {
+	envVal := os.Getenv("DISCOVERY_SPEC_DRIFT_CHECK_ENABLED")
+	if envVal == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(envVal)
+	if err != nil {
+		log.Errorf("Failed to parse DISCOVERY_SPEC_DRIFT_CHECK_ENABLED value = '%s' with err = '%s', using default = false", envVal, err)
+		return false
+	}
+	return enabled
+}
+
+func getDiscoveryTlsInsecure() bool {
+	envVal := os.Getenv("DISCOVERY_TLS_INSECURE")
+	if envVal == "" {
+		return false
+	}
+	insecure, err := strconv.ParseBool(envVal)
+	if err != nil {
+		log.Errorf("Failed to parse DISCOVERY_TLS_INSECURE value = '%s' with err = '%s', using default = false", envVal, err)
+		return false
+	}
+	return insecure
+}
+
+func getLeaderElectionEnabled() bool {
+	envVal := os.Getenv("LEADER_ELECTION_ENABLED")
+	if envVal == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(envVal)
+	if err != nil {
+		log.Errorf("Failed to parse LEADER_ELECTION_ENABLED value = '%s' with err = '%s', using default = false", envVal, err)
+		return false
+	}
+	return enabled
+}
+
+func getDiscoveryMaxRetries() int {
+	valueStr := os.Getenv("DISCOVERY_MAX_RETRIES")
+	if valueStr == "" {
+		return 2
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < 0 {
+		log.Errorf("Failed to parse DISCOVERY_MAX_RETRIES value = '%s', expected non-negative integer, using default = %d", valueStr, 2)
+		return 2
+	}
+	return value
+}
+
+func getDiscoveryRetryBackoff() time.Duration {
+	return time.Millisecond * time.Duration(getPositiveIntEnv("DISCOVERY_RETRY_BACKOFF_MS", 500))
+}
+
+func getDiscoveryRetryMaxBackoff() time.Duration {
+	return time.Millisecond * time.Duration(getPositiveIntEnv("DISCOVERY_RETRY_MAX_BACKOFF_MS", 5000))
+}
+
+func getDiscoveryReadinessTimeout() time.Duration {
+	return time.Second * time.Duration(getPositiveIntEnv("DISCOVERY_READINESS_TIMEOUT_SEC", 300))
+}
+
+func getPositiveIntEnv(name string, defaultValue int) int {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value <= 0 {
+		log.Errorf("Failed to parse %s value = '%s', expected positive integer, using default = %d", name, valueStr, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
 func validateSlugOnlyCharacters(value string) error {
 	if value == "" {
 		return fmt.Errorf("value cannot be empty")
@@ -1,20 +1,137 @@
 package utils
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Netcracker/qubership-apihub-agent/view"
 )
 
+var discoveryLimiter = NewConcurrencyLimiter(0, 0)
+var userRequestLimiter = NewConcurrencyLimiter(0, 0)
+
+// SetDiscoveryConcurrencyLimits limits the number of concurrent discovery requests globally and per target service (host:port).
+// Requests made for the user waiting for the response have their own per service limit, so they don't queue behind background discovery.
+func SetDiscoveryConcurrencyLimits(globalLimit int, perServiceLimit int) {
+	discoveryLimiter = NewConcurrencyLimiter(globalLimit, perServiceLimit)
+	userRequestLimiter = NewConcurrencyLimiter(0, perServiceLimit)
+}
+
+type userRequestKey struct{}
+
+// WithUserRequest marks the requests made with the context as the ones the user is waiting for
+func WithUserRequest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, userRequestKey{}, true)
+}
+
+var discoveryTransport http.RoundTripper = http.DefaultTransport
+var discoveryTlsVerified = true
+
+// SetDiscoveryTlsConfig configures TLS of discovery requests. Server certificates are verified against system CAs and the CA bundle if it's set,
+// the verification is skipped only if insecure is set. Client certificate is presented if both its cert and key are set.
+func SetDiscoveryTlsConfig(caBundlePath string, clientCertPath string, clientKeyPath string, insecure bool) error {
+	tlsConfig := &tls.Config{}
+	if insecure {
+		tlsConfig.InsecureSkipVerify = true
+	} else if caBundlePath != "" {
+		caBundle, err := os.ReadFile(caBundlePath)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle %s: %w", caBundlePath, err)
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(caBundle) {
+			return fmt.Errorf("no certificates found in CA bundle %s", caBundlePath)
+		}
+		tlsConfig = &tls.Config{RootCAs: rootCAs}
+	}
+	if clientCertPath != "" || clientKeyPath != "" {
+		if clientCertPath == "" || clientKeyPath == "" {
+			return fmt.Errorf("both client certificate and key are required for mTLS")
+		}
+		clientCert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate %s: %w", clientCertPath, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	discoveryTransport = transport
+	discoveryTlsVerified = !tlsConfig.InsecureSkipVerify
+	return nil
+}
+
+// IsDiscoveryTlsVerified returns true if server certificates of discovery requests are verified
+func IsDiscoveryTlsVerified() bool {
+	return discoveryTlsVerified
+}
+
+// MakeDiscoveryHttpClient makes client which limits concurrent requests. The timeout starts when the request gets the limiter slot,
+// so the time spent in the queue doesn't fail the request.
 func MakeDiscoveryHttpClient(timeout time.Duration) http.Client {
-	return http.Client{Timeout: timeout, CheckRedirect: func(req *http.Request, via []*http.Request) error {
+	transport := &limitedTransport{limiter: discoveryLimiter, userLimiter: userRequestLimiter, timeout: timeout, next: discoveryTransport}
+	return http.Client{Transport: transport, CheckRedirect: func(req *http.Request, via []*http.Request) error {
 		return http.ErrUseLastResponse
 	}}
 }
 
+// limitedTransport holds a limiter slot until the response body is closed, since the connection is busy until then
+type limitedTransport struct {
+	limiter     ConcurrencyLimiter
+	userLimiter ConcurrencyLimiter
+	timeout     time.Duration
+	next        http.RoundTripper
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter := t.limiter
+	if req.Context().Value(userRequestKey{}) != nil {
+		limiter = t.userLimiter
+	}
+	release, err := limiter.Acquire(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+	}
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() {
+		cancel()
+		release()
+	}}
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
 func EscapeSpaces(s string) string {
 	return strings.ReplaceAll(s, " ", "%20")
 }
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDiscoveryTlsConfigTrustsCaBundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer func() {
+		discoveryTransport = http.DefaultTransport
+		discoveryTlsVerified = true
+	}()
+
+	caBundlePath := filepath.Join(t.TempDir(), "ca.crt")
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caBundlePath, caBundle, 0600))
+
+	assert.NoError(t, SetDiscoveryTlsConfig(caBundlePath, "", "", false))
+	assert.True(t, IsDiscoveryTlsVerified())
+	assertDiscoveryRequestSucceeds(t, server.URL)
+
+	assert.Error(t, SetDiscoveryTlsConfig("", "client.crt", "", false))
+}
+
+func TestSetDiscoveryTlsConfigVerifiesCertificatesUnlessInsecure(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer func() {
+		discoveryTransport = http.DefaultTransport
+		discoveryTlsVerified = true
+	}()
+
+	assert.NoError(t, SetDiscoveryTlsConfig("", "", "", false))
+	assert.True(t, IsDiscoveryTlsVerified())
+	client := MakeDiscoveryHttpClient(5 * time.Second)
+	_, err := client.Get(server.URL)
+	assert.Error(t, err)
+
+	assert.NoError(t, SetDiscoveryTlsConfig("", "", "", true))
+	assert.False(t, IsDiscoveryTlsVerified())
+	assertDiscoveryRequestSucceeds(t, server.URL)
+}
+
+func assertDiscoveryRequestSucceeds(t *testing.T, url string) {
+	client := MakeDiscoveryHttpClient(5 * time.Second)
+	resp, err := client.Get(url)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// ConcurrencyLimiter bounds the number of concurrent operations globally and per key (e.g. per target host).
+// Zero or negative limit means no limit.
+type ConcurrencyLimiter interface {
+	// Acquire blocks until a slot is available for the key or ctx is done. Returned func must be called to release the slot.
+	Acquire(ctx context.Context, key string) (func(), error)
+}
+
+func NewConcurrencyLimiter(globalLimit int, perKeyLimit int) ConcurrencyLimiter {
+	var global chan struct{}
+	if globalLimit > 0 {
+		global = make(chan struct{}, globalLimit)
+	}
+	return &concurrencyLimiterImpl{
+		global:      global,
+		perKeyLimit: perKeyLimit,
+		perKey:      map[string]*keySemaphore{},
+	}
+}
+
+type concurrencyLimiterImpl struct {
+	global      chan struct{}
+	perKeyLimit int
+	perKey      map[string]*keySemaphore
+	perKeyMutex sync.Mutex
+}
+
+type keySemaphore struct {
+	slots chan struct{}
+	users int
+}
+
+func (c *concurrencyLimiterImpl) Acquire(ctx context.Context, key string) (func(), error) {
+	// acquire per key slot first, so requests waiting for a busy key do not hold global slots
+	releaseKey, err := c.acquireKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if c.global != nil {
+		select {
+		case c.global <- struct{}{}:
+		case <-ctx.Done():
+			releaseKey()
+			return nil, ctx.Err()
+		}
+	}
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			if c.global != nil {
+				<-c.global
+			}
+			releaseKey()
+		})
+	}, nil
+}
+
+func (c *concurrencyLimiterImpl) acquireKey(ctx context.Context, key string) (func(), error) {
+	if c.perKeyLimit <= 0 {
+		return func() {}, nil
+	}
+	c.perKeyMutex.Lock()
+	sem, exists := c.perKey[key]
+	if !exists {
+		sem = &keySemaphore{slots: make(chan struct{}, c.perKeyLimit)}
+		c.perKey[key] = sem
+	}
+	sem.users++
+	c.perKeyMutex.Unlock()
+
+	release := func() {
+		c.perKeyMutex.Lock()
+		defer c.perKeyMutex.Unlock()
+		sem.users--
+		if sem.users == 0 {
+			delete(c.perKey, key)
+		}
+	}
+
+	select {
+	case sem.slots <- struct{}{}:
+		return func() {
+			<-sem.slots
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
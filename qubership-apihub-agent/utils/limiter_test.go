@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiterPerKey(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2, 1)
+
+	releaseA, err := limiter.Acquire(context.Background(), "a")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, "a")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	releaseB, err := limiter.Acquire(context.Background(), "b")
+	assert.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, "c")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	releaseA()
+	releaseA()
+	releaseC, err := limiter.Acquire(context.Background(), "c")
+	assert.NoError(t, err)
+
+	releaseB()
+	releaseC()
+}
+
+func TestDiscoveryClientTimeoutExcludesQueueTime(t *testing.T) {
+	SetDiscoveryConcurrencyLimits(1, 1)
+	defer SetDiscoveryConcurrencyLimits(0, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// the second request waits for the first one longer than its timeout, but is not failed by the wait
+	client := MakeDiscoveryHttpClient(300 * time.Millisecond)
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, <-errs)
+	}
+}
+
+func TestDiscoveryClientUserRequestsBypassDiscoveryQueue(t *testing.T) {
+	SetDiscoveryConcurrencyLimits(1, 1)
+	defer SetDiscoveryConcurrencyLimits(0, 0)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := MakeDiscoveryHttpClient(5 * time.Second)
+	go func() {
+		resp, err := client.Get(server.URL + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(WithUserRequest(context.Background()), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/fast", nil)
+	// the discovery limiter slot of the host is busy, but the user request has its own one
+	resp, err := client.Do(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+}
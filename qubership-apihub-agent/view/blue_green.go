@@ -0,0 +1,20 @@
+package view
+
+// BlueGreen groups blue-green versions of the service, e.g. my-service-v1 and my-service-v2 which share my-service name
+type BlueGreen struct {
+	Version    string   `json:"version"`
+	Active     bool     `json:"active"`
+	SiblingIds []string `json:"siblingIds"` // ids of other versions of the service
+}
+
+// BlueGreenComparison is a difference between documents of two blue-green versions of the service
+type BlueGreenComparison struct {
+	WorkspaceId       string                 `json:"workspaceId"`
+	Namespace         string                 `json:"namespace"`
+	ServiceName       string                 `json:"serviceName"`
+	ServiceId         string                 `json:"serviceId"`
+	TargetServiceId   string                 `json:"targetServiceId"`
+	OnlyInServiceDocs []DiscoveryJobDocument `json:"onlyInServiceDocuments"`
+	OnlyInTargetDocs  []DiscoveryJobDocument `json:"onlyInTargetDocuments"`
+	ChangedDocuments  []ChangedDocument      `json:"changedDocuments"`
+}
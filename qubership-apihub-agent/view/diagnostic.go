@@ -4,13 +4,25 @@ type EndpointCallInfo struct {
 	Path         string `json:"path"` // Relative path (e.g., "/v3/api-docs")
 	StatusCode   int    `json:"statusCode,omitempty"`
 	ErrorSummary string `json:"errorSummary,omitempty"`
+
+	Attempts []EndpointCallAttempt `json:"attempts,omitempty"` // All attempts if the call was retried
+}
+
+type EndpointCallAttempt struct {
+	StatusCode   int    `json:"statusCode,omitempty"`
+	ErrorSummary string `json:"errorSummary,omitempty"`
+	DurationMs   int64  `json:"durationMs"`
 }
 
 type ServiceDiagnostic struct {
 	EndpointCalls []EndpointCallInfo `json:"endpointCalls,omitempty"` // Failed discovery attempts
+	ConfigErrors  []string           `json:"configErrors,omitempty"`  // Errors of discovery config annotation
+	SecretError   string             `json:"secretError,omitempty"`   // Failed to read credentials from discovery Secret
+	RetriedCalls  []EndpointCallInfo `json:"retriedCalls,omitempty"`  // Calls succeeded after retries
 }
 
 type DiscoveryResult struct {
 	Documents     []Document
 	EndpointCalls []EndpointCallInfo
+	RetriedCalls  []EndpointCallInfo
 }
@@ -0,0 +1,126 @@
+package view
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CustomK8sDiscoveryConfig holds JSON or YAML discovery config of the service, see DiscoveryConfig
+const CustomK8sDiscoveryConfig = "apihub-discovery-config"
+
+// DiscoveryConfig is a structured alternative to per kind url annotations
+type DiscoveryConfig struct {
+	// DisableDefaultUrls disables probing of default urls, so only urls and documents from annotations are requested
+	DisableDefaultUrls bool                          `yaml:"disableDefaultUrls"`
+	Urls               map[DiscoveryUrlKind][]string `yaml:"urls"`
+	Documents          []DiscoveryConfigDocument     `yaml:"documents"`
+}
+
+type DiscoveryConfigDocument struct {
+	Url        string `json:"url" yaml:"url"`
+	Type       string `json:"type" yaml:"type"` // document type or api type
+	Name       string `json:"name,omitempty" yaml:"name"`
+	XApiKind   string `json:"x-api-kind,omitempty" yaml:"x-api-kind"`
+	Required   *bool  `json:"required,omitempty" yaml:"required"` // true if not set
+	TimeoutSec int    `json:"timeoutSec,omitempty" yaml:"timeoutSec"`
+	Port       string `json:"port,omitempty" yaml:"port"` // name or number of the service port, the document is requested on every discovery port if not set
+}
+
+func (d DiscoveryConfigDocument) IsRequired() bool {
+	return d.Required == nil || *d.Required
+}
+
+// IsServedOn checks if the document is requested on the port
+func (d DiscoveryConfigDocument) IsServedOn(portName string, portNumber int32) bool {
+	port := strings.TrimSpace(d.Port)
+	return port == "" || port == portName || port == strconv.Itoa(int(portNumber))
+}
+
+// MakeDocumentUrl returns url of the document relative to the service
+func (d DiscoveryConfigDocument) MakeDocumentUrl() string {
+	return strings.ReplaceAll(d.Url, " ", "%20")
+}
+
+// ParseDiscoveryConfig returns nil config if the annotation is not set. Invalid documents are skipped and reported in errors.
+func ParseDiscoveryConfig(annotations map[string]string) (*DiscoveryConfig, []string) {
+	value := strings.TrimSpace(annotations[CustomK8sDiscoveryConfig])
+	if value == "" {
+		return nil, nil
+	}
+	config := &DiscoveryConfig{}
+	// YAML parser accepts JSON as well
+	if err := yaml.UnmarshalStrict([]byte(value), config); err != nil {
+		return nil, []string{fmt.Sprintf("failed to parse %s annotation: %s", CustomK8sDiscoveryConfig, err)}
+	}
+
+	var errs []string
+	for kind := range config.Urls {
+		if !validDiscoveryUrlKind(kind) {
+			errs = append(errs, fmt.Sprintf("%s annotation: unknown urls kind '%s'", CustomK8sDiscoveryConfig, kind))
+			delete(config.Urls, kind)
+		}
+	}
+	documents := make([]DiscoveryConfigDocument, 0, len(config.Documents))
+	for i, document := range config.Documents {
+		switch {
+		case document.Url == "":
+			errs = append(errs, fmt.Sprintf("%s annotation: url of document #%d is not set", CustomK8sDiscoveryConfig, i+1))
+		case getDocumentApiType(document.Type) == "":
+			errs = append(errs, fmt.Sprintf("%s annotation: unknown type '%s' of document %s", CustomK8sDiscoveryConfig, document.Type, document.Url))
+		case document.TimeoutSec < 0:
+			errs = append(errs, fmt.Sprintf("%s annotation: negative timeout of document %s", CustomK8sDiscoveryConfig, document.Url))
+		default:
+			documents = append(documents, document)
+		}
+	}
+	config.Documents = documents
+	return config, errs
+}
+
+// MakeDocumentRefs converts config documents to refs. Default timeout is used if document timeout is not set.
+func (c *DiscoveryConfig) MakeDocumentRefs(defaultTimeout time.Duration) []DocumentRef {
+	if c == nil {
+		return nil
+	}
+	refs := make([]DocumentRef, 0, len(c.Documents))
+	for _, document := range c.Documents {
+		timeout := defaultTimeout
+		if document.TimeoutSec > 0 {
+			timeout = time.Duration(document.TimeoutSec) * time.Second
+		}
+		refs = append(refs, DocumentRef{
+			Url:      document.MakeDocumentUrl(),
+			XApiKind: document.XApiKind,
+			Name:     document.Name,
+			ApiType:  getDocumentApiType(document.Type),
+			Required: document.IsRequired(),
+			Timeout:  timeout,
+		})
+	}
+	return refs
+}
+
+// getDocumentApiType accepts both document types (openapi-3-0, graphql, etc.) and api types (rest, smartplug, etc.). Returns empty string for unknown type.
+func getDocumentApiType(documentType string) ApiType {
+	if ValidDocumentType(documentType) {
+		return DocTypeToApiType(documentType)
+	}
+	switch apiType := ApiType(documentType); apiType {
+	case ATRest, ATGraphql, ATMarkdown, ATJsonSchema, ATSmartplug, ATUnknown:
+		return apiType
+	}
+	return ""
+}
+
+func validDiscoveryUrlKind(kind DiscoveryUrlKind) bool {
+	for _, validKind := range DiscoveryUrlKinds {
+		if kind == validKind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package view
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeDocDiscoveryUrlsWithDiscoveryConfig(t *testing.T) {
+	urls := MakeDocDiscoveryUrls(map[string]string{CustomK8sDiscoveryConfig: `
+disableDefaultUrls: true
+urls:
+  openapi: [/api/v1/docs, /api/v2/docs]
+  smartplugConfig: [/plugins/config]
+documents:
+  - url: /specs/orders.json
+    type: openapi-3-0
+    name: Orders
+    x-api-kind: BWC
+    timeoutSec: 30
+  - url: /specs/guide.md
+    type: markdown
+    required: false
+    port: 9090
+  - url: /specs/unknown
+    type: soap
+`})
+	assert.Equal(t, []string{"/api/v1/docs", "/api/v2/docs"}, urls.Openapi)
+	assert.Equal(t, []string{"/plugins/config"}, urls.SmartplugConfig)
+	assert.Empty(t, urls.SwaggerConfig)
+	assert.Empty(t, urls.ApihubConfig)
+	assert.Len(t, urls.ConfigErrors, 1)
+
+	refs := urls.Config.MakeDocumentRefs(time.Second)
+	if assert.Len(t, refs, 2) {
+		assert.Equal(t, DocumentRef{Url: "/specs/orders.json", Name: "Orders", XApiKind: "BWC", ApiType: ATRest, Required: true, Timeout: 30 * time.Second}, refs[0])
+		assert.Equal(t, DocumentRef{Url: "/specs/guide.md", ApiType: ATMarkdown, Required: false, Timeout: time.Second}, refs[1])
+	}
+	assert.True(t, urls.Config.Documents[0].IsServedOn("web", 8080))
+	assert.True(t, urls.Config.Documents[1].IsServedOn("management", 9090))
+	assert.False(t, urls.Config.Documents[1].IsServedOn("web", 8080))
+}
+
+func TestMakeDocDiscoveryUrlsWithInvalidDiscoveryConfig(t *testing.T) {
+	urls := MakeDocDiscoveryUrls(map[string]string{CustomK8sDiscoveryConfig: `{"documents": [`})
+	assert.Nil(t, urls.Config)
+	assert.Len(t, urls.ConfigErrors, 1)
+	assert.Equal(t, defaultOpenapiUrls, urls.Openapi)
+
+	urls = MakeDocDiscoveryUrls(map[string]string{CustomK8sDiscoveryConfig: `{"documents": [{"url": "/openapi", "type": "rest"}]}`})
+	assert.Empty(t, urls.ConfigErrors)
+	assert.Equal(t, defaultOpenapiUrls, urls.Openapi)
+	assert.Len(t, urls.Config.MakeDocumentRefs(time.Second), 1)
+}
@@ -0,0 +1,48 @@
+package view
+
+type DiscoveryDiff struct {
+	Namespace       string                 `json:"namespace"`
+	WorkspaceId     string                 `json:"workspaceId"`
+	FromJobId       string                 `json:"fromJobId"`
+	ToJobId         string                 `json:"toJobId,omitempty"` // empty if compared with the current discovery result
+	AddedServices   []DiscoveryDiffService `json:"addedServices"`
+	RemovedServices []DiscoveryDiffService `json:"removedServices"`
+	ChangedServices []DiscoveryDiffService `json:"changedServices"`
+}
+
+type DiscoveryDiffService struct {
+	Id               string                 `json:"id"`
+	Name             string                 `json:"serviceName"`
+	AddedDocuments   []DiscoveryJobDocument `json:"addedDocuments,omitempty"`
+	RemovedDocuments []DiscoveryJobDocument `json:"removedDocuments,omitempty"`
+	ChangedDocuments []DiscoveryJobDocument `json:"changedDocuments,omitempty"`
+}
+
+// NamespaceComparison is a difference between discovery results of two namespaces. Services are matched by name without blue-green suffix.
+type NamespaceComparison struct {
+	WorkspaceId         string                       `json:"workspaceId"`
+	Namespace           string                       `json:"namespace"`
+	TargetNamespace     string                       `json:"targetNamespace"`
+	OnlyInNamespace     []NamespaceComparisonService `json:"onlyInNamespace"`
+	OnlyInTarget        []NamespaceComparisonService `json:"onlyInTarget"`
+	ChangedServices     []NamespaceComparisonService `json:"changedServices"`
+	NotComparedServices []NamespaceComparisonService `json:"notComparedServices"` // services without ready pods in any of namespaces
+}
+
+type NamespaceComparisonService struct {
+	Name                string                 `json:"serviceName"`
+	ServiceIds          []string               `json:"serviceIds,omitempty"`
+	TargetServiceIds    []string               `json:"targetServiceIds,omitempty"`
+	OnlyInNamespaceDocs []DiscoveryJobDocument `json:"onlyInNamespaceDocuments,omitempty"`
+	OnlyInTargetDocs    []DiscoveryJobDocument `json:"onlyInTargetDocuments,omitempty"`
+	ChangedDocuments    []ChangedDocument      `json:"changedDocuments,omitempty"`
+}
+
+type ChangedDocument struct {
+	DocPath    string `json:"docPath"`
+	Port       int32  `json:"port,omitempty"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Hash       string `json:"hash"`
+	TargetHash string `json:"targetHash"`
+}
@@ -0,0 +1,17 @@
+package view
+
+type DiscoveryEventType string
+
+const DiscoveryEventService DiscoveryEventType = "service"
+const DiscoveryEventStatus DiscoveryEventType = "status"
+
+type DiscoveryEvent struct {
+	Type    DiscoveryEventType
+	Service *Service
+	Status  *DiscoveryStatusEvent
+}
+
+type DiscoveryStatusEvent struct {
+	Status StatusEnum `json:"status"`
+	Debug  string     `json:"debug"`
+}
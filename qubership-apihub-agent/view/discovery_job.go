@@ -0,0 +1,58 @@
+package view
+
+import "time"
+
+type DiscoveryJob struct {
+	Id                  string                `json:"id"`
+	Namespace           string                `json:"namespace"`
+	WorkspaceId         string                `json:"workspaceId"`
+	CreatedBy           string                `json:"createdBy"`
+	Status              StatusEnum            `json:"status"`
+	StartedAt           time.Time             `json:"startedAt"`
+	FinishedAt          *time.Time            `json:"finishedAt,omitempty"`
+	DurationMs          int64                 `json:"durationMs"`
+	ServicesCount       int                   `json:"servicesCount"`
+	FailedServicesCount int                   `json:"failedServicesCount"`
+	Error               string                `json:"error,omitempty"`
+	Services            []DiscoveryJobService `json:"services,omitempty"`
+}
+
+type DiscoveryJobService struct {
+	Id             string                 `json:"id"`
+	Name           string                 `json:"serviceName"`
+	DurationMs     int64                  `json:"durationMs"`
+	DocumentsCount int                    `json:"documentsCount"`
+	Error          string                 `json:"error,omitempty"`
+	NotReady       bool                   `json:"notReady,omitempty"`
+	Documents      []DiscoveryJobDocument `json:"documents,omitempty"`
+}
+
+type DiscoveryJobDocument struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	DocPath string `json:"docPath"`
+	Port    int32  `json:"port,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+}
+
+func MakeDiscoveryJobDocuments(documents []Document) []DiscoveryJobDocument {
+	result := make([]DiscoveryJobDocument, len(documents))
+	for i, doc := range documents {
+		result[i] = DiscoveryJobDocument{
+			Name:    doc.Name,
+			Type:    doc.Type,
+			DocPath: doc.DocPath,
+			Port:    doc.Port,
+			Hash:    doc.Hash,
+		}
+	}
+	return result
+}
+
+type DiscoveryJobsResponse struct {
+	Jobs []DiscoveryJob `json:"jobs"`
+}
+
+type DiscoveryStartResponse struct {
+	JobId string `json:"jobId"`
+}
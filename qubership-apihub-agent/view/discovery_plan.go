@@ -0,0 +1,36 @@
+package view
+
+type DiscoveryUrlSource string
+
+const DiscoveryUrlSourceAnnotation DiscoveryUrlSource = "annotation"
+const DiscoveryUrlSourceDefault DiscoveryUrlSource = "default"
+
+type DiscoveryPlan struct {
+	ServiceId       string                    `json:"serviceId"`
+	ServiceName     string                    `json:"serviceName"`
+	Namespace       string                    `json:"namespace"`
+	Excluded        bool                      `json:"excluded"`
+	ExcludedByLabel string                    `json:"excludedByLabel,omitempty"`
+	External        bool                      `json:"external,omitempty"`
+	BaseUrl         string                    `json:"baseUrl"`
+	Port            *DiscoveryPlanPort        `json:"port,omitempty"` // primary port, used for the service url
+	Ports           []DiscoveryPlanPort       `json:"ports"`
+	Urls            []DiscoveryPlanUrl        `json:"urls"`
+	Documents       []DiscoveryConfigDocument `json:"documents,omitempty"` // documents from discovery config annotation
+	ConfigErrors    []string                  `json:"configErrors,omitempty"`
+	Secret          string                    `json:"secret,omitempty"` // Secret with credentials to fetch the documents
+}
+
+type DiscoveryPlanPort struct {
+	Name    string `json:"name,omitempty"`
+	Port    int32  `json:"port"`
+	BaseUrl string `json:"baseUrl"`
+}
+
+type DiscoveryPlanUrl struct {
+	Url        string             `json:"url"`
+	Kind       DiscoveryUrlKind   `json:"kind"`
+	Source     DiscoveryUrlSource `json:"source"`
+	Annotation string             `json:"annotation,omitempty"`
+	Runners    []string           `json:"runners"`
+}
@@ -0,0 +1,9 @@
+package view
+
+const AllNamespaces = "*"
+
+type DiscoverySchedule struct {
+	Cron        string   `json:"cron"`
+	Namespaces  []string `json:"namespaces"`
+	WorkspaceId string   `json:"workspaceId"`
+}
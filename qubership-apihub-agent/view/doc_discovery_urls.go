@@ -18,6 +18,46 @@ const CustomK8sGraphqlUrl = "apihub-graphql-url"
 const CustomK8sGraphqlIntUrl = "apihub-graphql-int-url"
 const CustomK8sGraphqlConfigUrl = "apihub-graphql-config-url"
 
+// CustomK8sDiscoveryPorts lists comma separated names or numbers of the service ports to probe
+const CustomK8sDiscoveryPorts = "apihub-discovery-ports"
+
+// CustomK8sDiscoveryTlsPorts lists comma separated names or numbers of the service ports which serve TLS
+const CustomK8sDiscoveryTlsPorts = "apihub-discovery-tls-ports"
+
+// annotations of ExternalName service which define the target outside the cluster
+const CustomK8sExternalHost = "apihub-external-host"
+const CustomK8sExternalPort = "apihub-external-port"
+const CustomK8sExternalScheme = "apihub-external-scheme"
+
+// CustomK8sBlueGreenActive marks the active blue-green version of the service. Can be set as annotation or label of the service or its pods.
+const CustomK8sBlueGreenActive = "apihub-bg-active"
+
+// CustomK8sDiscoverySecret names the Secret in the service namespace with credentials to fetch the service documents
+const CustomK8sDiscoverySecret = "apihub-discovery-secret"
+
+type DiscoveryUrlKind string
+
+const DUKApihubConfig DiscoveryUrlKind = "apihubConfig"
+const DUKSwaggerConfig DiscoveryUrlKind = "swaggerConfig"
+const DUKOpenapi DiscoveryUrlKind = "openapi"
+const DUKGraphqlConfig DiscoveryUrlKind = "graphqlConfig"
+const DUKGraphqlSchema DiscoveryUrlKind = "graphqlSchema"
+const DUKGraphqlIntrospection DiscoveryUrlKind = "graphqlIntrospection"
+const DUKSmartplugConfig DiscoveryUrlKind = "smartplugConfig"
+
+// DiscoveryUrlKinds is ordered the same way the urls are probed
+var DiscoveryUrlKinds = []DiscoveryUrlKind{DUKApihubConfig, DUKSwaggerConfig, DUKOpenapi, DUKGraphqlConfig, DUKGraphqlSchema, DUKGraphqlIntrospection, DUKSmartplugConfig}
+
+// annotations which override default urls of the kind
+var discoveryUrlKindAnnotations = map[DiscoveryUrlKind]string{
+	DUKApihubConfig:         CustomK8sApihubConfigUrl,
+	DUKSwaggerConfig:        CustomK8sSwaggerConfigUrl,
+	DUKOpenapi:              CustomK8sOpenapiUrl,
+	DUKGraphqlConfig:        CustomK8sGraphqlConfigUrl,
+	DUKGraphqlSchema:        CustomK8sGraphqlUrl,
+	DUKGraphqlIntrospection: CustomK8sGraphqlIntUrl,
+}
+
 type DocumentDiscoveryUrls struct {
 	ApihubConfig  []string
 	SwaggerConfig []string
@@ -29,11 +69,13 @@ type DocumentDiscoveryUrls struct {
 	GraphqlIntrospection []string
 
 	SmartplugConfig []string
+
+	Config       *DiscoveryConfig // nil if discovery config annotation is not set or invalid
+	ConfigErrors []string
 }
 
 func MakeDocDiscoveryUrls(annotations map[string]string) DocumentDiscoveryUrls {
 	result := DocumentDiscoveryUrls{}
-	//TODO: may be some custom annotation for smartplug url?
 	for key, value := range annotations {
 		switch key {
 		case CustomK8sApihubConfigUrl:
@@ -50,6 +92,15 @@ func MakeDocDiscoveryUrls(annotations map[string]string) DocumentDiscoveryUrls {
 			result.GraphqlConfig = append(result.GraphqlConfig, value)
 		}
 	}
+	result.Config, result.ConfigErrors = ParseDiscoveryConfig(annotations)
+	if result.Config != nil {
+		for _, kind := range DiscoveryUrlKinds {
+			result.addUrls(kind, result.Config.Urls[kind])
+		}
+		if result.Config.DisableDefaultUrls {
+			return result
+		}
+	}
 	if len(result.ApihubConfig) == 0 {
 		result.ApihubConfig = append(result.ApihubConfig, defaultApihubConfigUrls...)
 	}
@@ -68,7 +119,79 @@ func MakeDocDiscoveryUrls(annotations map[string]string) DocumentDiscoveryUrls {
 	if len(result.GraphqlConfig) == 0 {
 		result.GraphqlConfig = append(result.GraphqlConfig, defaultGraphqlConfigUrls...)
 	}
-	result.SmartplugConfig = append(result.SmartplugConfig, defaultSmartlplugConfigUrls...)
+	if len(result.SmartplugConfig) == 0 {
+		result.SmartplugConfig = append(result.SmartplugConfig, defaultSmartlplugConfigUrls...)
+	}
+	return result
+}
+
+func (u *DocumentDiscoveryUrls) addUrls(kind DiscoveryUrlKind, urls []string) {
+	switch kind {
+	case DUKApihubConfig:
+		u.ApihubConfig = append(u.ApihubConfig, urls...)
+	case DUKSwaggerConfig:
+		u.SwaggerConfig = append(u.SwaggerConfig, urls...)
+	case DUKOpenapi:
+		u.Openapi = append(u.Openapi, urls...)
+	case DUKGraphqlConfig:
+		u.GraphqlConfig = append(u.GraphqlConfig, urls...)
+	case DUKGraphqlSchema:
+		u.GraphqlSchema = append(u.GraphqlSchema, urls...)
+	case DUKGraphqlIntrospection:
+		u.GraphqlIntrospection = append(u.GraphqlIntrospection, urls...)
+	case DUKSmartplugConfig:
+		u.SmartplugConfig = append(u.SmartplugConfig, urls...)
+	}
+}
+
+func (u DocumentDiscoveryUrls) GetUrls(kind DiscoveryUrlKind) []string {
+	switch kind {
+	case DUKApihubConfig:
+		return u.ApihubConfig
+	case DUKSwaggerConfig:
+		return u.SwaggerConfig
+	case DUKOpenapi:
+		return u.Openapi
+	case DUKGraphqlConfig:
+		return u.GraphqlConfig
+	case DUKGraphqlSchema:
+		return u.GraphqlSchema
+	case DUKGraphqlIntrospection:
+		return u.GraphqlIntrospection
+	case DUKSmartplugConfig:
+		return u.SmartplugConfig
+	}
+	return nil
+}
+
+// MakeDiscoveryPlanUrls lists urls produced by MakeDocDiscoveryUrls along with their source. Runners are not filled.
+func MakeDiscoveryPlanUrls(annotations map[string]string) []DiscoveryPlanUrl {
+	urls := MakeDocDiscoveryUrls(annotations)
+	result := make([]DiscoveryPlanUrl, 0)
+	for _, kind := range DiscoveryUrlKinds {
+		source := DiscoveryUrlSourceDefault
+		annotation := ""
+		if key, ok := discoveryUrlKindAnnotations[kind]; ok {
+			if _, exists := annotations[key]; exists {
+				source = DiscoveryUrlSourceAnnotation
+				annotation = key
+			}
+		}
+		if urls.Config != nil && (len(urls.Config.Urls[kind]) > 0 || urls.Config.DisableDefaultUrls) {
+			source = DiscoveryUrlSourceAnnotation
+			if annotation == "" {
+				annotation = CustomK8sDiscoveryConfig
+			}
+		}
+		for _, url := range urls.GetUrls(kind) {
+			result = append(result, DiscoveryPlanUrl{
+				Url:        url,
+				Kind:       kind,
+				Source:     source,
+				Annotation: annotation,
+			})
+		}
+	}
 	return result
 }
 
@@ -17,8 +17,24 @@ type Document struct {
 	XApiKind   string `json:"xApiKind,omitempty"`
 	DocPath    string `json:"docPath"`
 	ConfigPath string `json:"configPath,omitempty"`
+	// Port of the service where the document was found
+	Port int32 `json:"port,omitempty"`
+	// BaseUrl of the port the document was found on, the scheme of the port may differ from the one of the service url
+	BaseUrl string `json:"baseUrl,omitempty"`
+
+	Hash         string               `json:"hash,omitempty"`
+	Size         int                  `json:"size,omitempty"`
+	ChangeStatus DocumentChangeStatus `json:"changeStatus,omitempty"`
 }
 
+// DocumentChangeStatus shows how the document changed since the previous discovery of the namespace
+type DocumentChangeStatus string
+
+const DocumentNew DocumentChangeStatus = "new"
+const DocumentChanged DocumentChangeStatus = "changed"
+const DocumentUnchanged DocumentChangeStatus = "unchanged"
+const DocumentRemoved DocumentChangeStatus = "removed"
+
 func (d *Document) ToDeprecated() Document_deprecated {
 	return Document_deprecated{
 		Name:     d.Name,
@@ -1,6 +1,14 @@
 package view
 
 type NamespacesListResponse struct {
-	Namespaces []string `json:"namespaces"`
-	CloudName  string   `json:"cloudName"`
+	Namespaces []string        `json:"namespaces"`
+	CloudName  string          `json:"cloudName"`
+	Items      []NamespaceItem `json:"items,omitempty"`
+}
+
+type NamespaceItem struct {
+	Name         string            `json:"name"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	ServiceCount *int              `json:"serviceCount,omitempty"`
 }
@@ -25,6 +25,12 @@ type Service struct {
 	ProxyServerUrl           string             `json:"proxyServerUrl,omitempty"`
 	Error                    string             `json:"error,omitempty"`
 	DiagnosticInfo           *ServiceDiagnostic `json:"diagnosticInfo,omitempty"`
+	NotReady                 bool               `json:"notReady,omitempty"`
+	RemovedDocuments         []Document         `json:"removedDocuments,omitempty"` // documents found by the previous discovery only
+	SpecDrift                *SpecDrift         `json:"specDrift,omitempty"`        // set if ready pods serve different documents content or it could not be compared
+	External                 bool               `json:"external,omitempty"`         // ExternalName service discovered against the host outside the cluster
+	BlueGreen                *BlueGreen         `json:"blueGreen,omitempty"`        // set if there are other blue-green versions of the service
+	DiscoverySecret          string             `json:"discoverySecret,omitempty"`  // Secret with credentials to fetch the service documents
 }
 
 func (s *Service) ToDeprecated() Service_deprecated {
@@ -54,6 +60,7 @@ type ServiceItem struct {
 	Annotations    map[string]string `json:"serviceAnnotations,omitempty"`
 	Pods           []string          `json:"servicePods,omitempty"`
 	ProxyServerUrl string            `json:"proxyServerUrl,omitempty"`
+	External       bool              `json:"external,omitempty"`
 }
 
 type StatusEnum string
@@ -62,6 +69,7 @@ const StatusNone StatusEnum = "none"
 const StatusRunning StatusEnum = "running"
 const StatusComplete StatusEnum = "complete"
 const StatusError StatusEnum = "error"
+const StatusCancelled StatusEnum = "cancelled"
 
 type ServiceListResponse_deprecated struct {
 	Services []Service_deprecated `json:"services"`
@@ -70,9 +78,10 @@ type ServiceListResponse_deprecated struct {
 }
 
 type ServiceListResponse struct {
-	Services []Service  `json:"services"`
-	Status   StatusEnum `json:"status"`
-	Debug    string     `json:"debug"`
+	Services        []Service  `json:"services"`
+	Status          StatusEnum `json:"status"`
+	Debug           string     `json:"debug"`
+	RemovedServices []Service  `json:"removedServices,omitempty"` // services found by the previous discovery only
 }
 
 type Baseline struct {
@@ -92,6 +101,8 @@ func BuildStatusFromString(str string) (StatusEnum, error) {
 		return StatusComplete, nil
 	case "error":
 		return StatusError, nil
+	case "cancelled":
+		return StatusCancelled, nil
 	}
 	return StatusNone, fmt.Errorf("unknown build status: %s", str)
 }
@@ -0,0 +1,28 @@
+package view
+
+// SpecDrift lists documents which differ between ready pods of the service and the ones which couldn't be compared
+type SpecDrift struct {
+	Pods         []string               `json:"pods"` // ready pods which were checked
+	Documents    []DocumentDrift        `json:"documents"`
+	Inconclusive []InconclusiveDocument `json:"inconclusive,omitempty"`
+}
+
+// InconclusiveDocument is the document which content couldn't be compared between pods, e.g. none of them served it
+type InconclusiveDocument struct {
+	FileId  string `json:"fileId"`
+	DocPath string `json:"docPath"`
+	Reason  string `json:"reason"`
+}
+
+type DocumentDrift struct {
+	FileId  string        `json:"fileId"`
+	DocPath string        `json:"docPath"`
+	Pods    []PodDocument `json:"pods"`
+}
+
+// PodDocument is the document content served by single pod. Hash is empty if the pod failed to serve the document.
+type PodDocument struct {
+	PodName string `json:"podName"`
+	Hash    string `json:"hash,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
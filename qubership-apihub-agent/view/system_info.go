@@ -11,10 +11,43 @@ type SystemInfo struct {
 	DiscoveryConfig  string        `json:"-"`
 	CloudName        string        `json:"-"`
 	AgentNamespace   string        `json:"-"`
-	ExcludeLabels    []string      `json:"-"`
+	IncludeLabels    string        `json:"-"`
+	ExcludeLabels    string        `json:"-"`
 	GroupingLabels   []string      `json:"-"`
 	AgentName        string        `json:"-"`
 	DiscoveryTimeout   time.Duration `json:"-"`
 	NamespacesCacheTTL time.Duration `json:"-"`
 	ServicesCacheTTL   time.Duration `json:"-"`
+
+	DiscoveryMaxConcurrentRequests           int `json:"-"`
+	DiscoveryMaxConcurrentRequestsPerService int `json:"-"`
+	DiscoveryMaxParallelNamespaces           int `json:"-"`
+
+	DiscoverySchedules    []DiscoverySchedule `json:"-"`
+	DiscoveryWatchEnabled bool                `json:"-"`
+
+	DiscoveryMaxRetries      int           `json:"-"`
+	DiscoveryRetryBackoff    time.Duration `json:"-"`
+	DiscoveryRetryMaxBackoff time.Duration `json:"-"`
+
+	DiscoveryReadinessTimeout time.Duration `json:"-"`
+
+	DiscoverySpecDriftCheckEnabled bool `json:"-"`
+
+	IncludeNamespaces      string `json:"-"`
+	ExcludeNamespaces      string `json:"-"`
+	IncludeNamespaceLabels string `json:"-"`
+	ExcludeNamespaceLabels string `json:"-"`
+
+	DiscoveryCaBundlePath   string `json:"-"`
+	DiscoveryClientCertPath string `json:"-"`
+	DiscoveryClientKeyPath  string `json:"-"`
+	DiscoveryTlsInsecure    bool   `json:"-"`
+
+	ServicesCacheStorePath string `json:"-"`
+	ServicesCacheRedisUrl  string `json:"-"`
+
+	LeaderElectionEnabled  bool   `json:"-"`
+	LeaderElectionLockFile string `json:"-"`
+	PodIp                  string `json:"-"`
 }